@@ -1,14 +1,20 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"net"
 	"os"
+
+	l4g "github.com/lerryxiao/log4go"
 )
 
 var (
-	port = flag.String("p", "12124", "Port number to listen on")
+	port    = flag.String("p", "12124", "Port number to listen on")
+	proto   = flag.String("proto", "udp", "Protocol to listen on: udp or tcp")
+	framing = flag.String("framing", l4g.FramingNone, "Framing of tcp streams, same as the writer's framing property: none (default), newline, octet or length")
 )
 
 func e(err error) {
@@ -18,25 +24,70 @@ func e(err error) {
 	}
 }
 
-func main() {
-	flag.Parse()
-
+// serveUDP every datagram is one record
+func serveUDP() {
 	// Bind to the port
-	bind, err := net.ResolveUDPAddr("0.0.0.0", *port)
+	bind, err := net.ResolveUDPAddr("udp", ":"+*port)
 	e(err)
 
 	// Create listener
 	listener, err := net.ListenUDP("udp", bind)
 	e(err)
 
-	fmt.Printf("Listening to port %s...\n", *port)
+	fmt.Printf("Listening to udp port %s...\n", *port)
+	buffer := make([]byte, 64*1024)
 	for {
-		// read into a new buffer
-		buffer := make([]byte, 1024)
-		_, _, err := listener.ReadFrom(buffer)
+		// read into the buffer
+		n, _, err := listener.ReadFrom(buffer)
 		e(err)
 
 		// log to standard output
-		fmt.Println(string(buffer))
+		fmt.Println(string(buffer[:n]))
+	}
+}
+
+// serveTCP split every connection into records by framing
+func serveTCP(framing string) {
+	listener, err := net.Listen("tcp", ":"+*port)
+	e(err)
+
+	fmt.Printf("Listening to tcp port %s with %s framing...\n", *port, framing)
+	if framing == l4g.FramingNone {
+		fmt.Println("Records are not split without framing, set the same framing on both sides, e.g. -framing newline")
+	}
+	for {
+		conn, err := listener.Accept()
+		e(err)
+		go func(conn net.Conn) {
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			for {
+				msg, err := l4g.ReadFrame(reader, framing)
+				if len(msg) > 0 {
+					fmt.Println(string(msg))
+				}
+				if err != nil {
+					if err != io.EOF {
+						fmt.Printf("%s: %s\n", conn.RemoteAddr(), err)
+					}
+					return
+				}
+			}
+		}(conn)
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	switch *proto {
+	case "udp":
+		serveUDP()
+	case "tcp":
+		f, err := l4g.ParseFraming(*framing)
+		e(err)
+		serveTCP(f)
+	default:
+		e(fmt.Errorf("unknown protocol %q", *proto))
 	}
 }
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	l4g "github.com/lerryxiao/log4go"
+)
+
+func main() {
+	log := l4g.NewLogger()
+	for _, filename := range []string{"example.yaml", "example.json"} {
+		if err := l4g.LoadConfigurationE(filename, log); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		log.Debug("Loaded %s", filename)
+		log.InfoKV("About that time, eh chaps?", "config", filename)
+	}
+	log.Close()
+	os.Remove("test.log")
+}
@@ -27,15 +27,55 @@ const (
 	EXCatError          = define.EXCatError
 	EXCatMetricCount    = define.EXCatMetricCount
 	EXCatMetricDuration = define.EXCatMetricDuration
+
+	ConfigXML  = define.ConfigXML
+	ConfigYAML = define.ConfigYAML
+	ConfigJSON = define.ConfigJSON
+
+	ColorAuto   = log.ColorAuto
+	ColorAlways = log.ColorAlways
+	ColorNever  = log.ColorNever
+
+	FieldTraceID = define.FieldTraceID
+	FieldSpanID  = define.FieldSpanID
+	FieldUserID  = define.FieldUserID
+
+	FramingNone    = log.FramingNone
+	FramingNewline = log.FramingNewline
+	FramingOctet   = log.FramingOctet
+	FramingLength  = log.FramingLength
+
+	SyslogRFC5424 = log.SyslogRFC5424
+	SyslogRFC3164 = log.SyslogRFC3164
 )
 
 // 函数定义
 var (
-	NewFileLogWriter    = log.NewFileLogWriter
-	NewHTTPLogWriter    = log.NewHTTPLogWriter
-	NewFormatLogWriter  = log.NewFormatLogWriter
-	NewSocketLogWriter  = log.NewSocketLogWriter
-	NewConsoleLogWriter = log.NewConsoleLogWriter
+	NewFileLogWriter       = log.NewFileLogWriter
+	NewSharedFileLogWriter = log.NewSharedFileLogWriter
+	NewHTTPLogWriter       = log.NewHTTPLogWriter
+	NewFormatLogWriter     = log.NewFormatLogWriter
+	NewSocketLogWriter     = log.NewSocketLogWriter
+	NewTLSSocketLogWriter  = log.NewTLSSocketLogWriter
+	NewSyslogLogWriter     = log.NewSyslogLogWriter
+	NewTLSSyslogLogWriter  = log.NewTLSSyslogLogWriter
+	NewSyslogFormatter     = log.NewSyslogFormatter
+	NewConsoleLogWriter    = log.NewConsoleLogWriter
+	NewJSONFormatter       = log.NewJSONFormatter
+	CompileFormat          = log.CompileFormat
+	RegisterFormatVerb     = log.RegisterFormatVerb
+	ParseFraming           = log.ParseFraming
+	ReadFrame              = log.ReadFrame
+
+	MakeFields = define.MakeFields
+
+	ContextFields            = define.ContextFields
+	ContextWithFields        = define.ContextWithFields
+	ContextWithTraceID       = define.ContextWithTraceID
+	ContextWithSpanID        = define.ContextWithSpanID
+	ContextWithUserID        = define.ContextWithUserID
+	RegisterContextKey       = define.RegisterContextKey
+	RegisterContextExtractor = define.RegisterContextExtractor
 )
 
 // Logger 日志过滤器组合
@@ -43,3 +83,30 @@ type Logger = define.Logger
 
 // LogRecord contains all of the pertinent information for each message
 type LogRecord = define.LogRecord
+
+// Field 结构化字段
+type Field = define.Field
+
+// Fields 结构化字段集合
+type Fields = define.Fields
+
+// ContextExtractor context字段提取器
+type ContextExtractor = define.ContextExtractor
+
+// ConfigError 配置错误集合
+type ConfigError = define.ConfigError
+
+// Filter 日志过滤器
+type Filter = define.Filter
+
+// SourceLevel 按调用来源设置的等级
+type SourceLevel = define.SourceLevel
+
+// Reopener 可重新打开输出目标的writer
+type Reopener = define.Reopener
+
+// TLSOptions TLS配置项
+type TLSOptions = log.TLSOptions
+
+// SyslogFormatter syslog消息格式化
+type SyslogFormatter = log.SyslogFormatter
@@ -0,0 +1,114 @@
+package log4go
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+// 常量定义
+const (
+	MaxLevelDuration = 24 * time.Hour // 临时等级最长持续时间
+)
+
+// LevelInfo 过滤器等级信息
+type LevelInfo struct {
+	Tag      string     `json:"tag"`
+	Level    string     `json:"level"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// levelHandler 过滤器等级管理
+type levelHandler struct {
+	log Logger
+}
+
+// LevelHandler 过滤器等级管理 http 接口
+// GET 列出所有过滤器及等级
+// POST/PUT 参数 tag, level, duration(可选, 如 10m)，带 duration 时到期自动恢复原等级
+func LevelHandler(log Logger) http.Handler {
+	return &levelHandler{log: log}
+}
+
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		infos := make([]LevelInfo, 0)
+		for _, tag := range h.log.Tags() {
+			if info, ok := h.getInfo(tag); ok {
+				infos = append(infos, info)
+			}
+		}
+		h.writeJSON(w, http.StatusOK, infos)
+	case http.MethodPost, http.MethodPut:
+		h.setLevel(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, PUT")
+		h.writeError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+	}
+}
+
+func (h *levelHandler) setLevel(w http.ResponseWriter, r *http.Request) {
+	tag := r.FormValue("tag")
+	if len(tag) <= 0 {
+		h.writeError(w, http.StatusBadRequest, "tag is required")
+		return
+	}
+	lvl := define.GetLevel(strings.ToLower(r.FormValue("level")))
+	if lvl == 0 {
+		h.writeError(w, http.StatusBadRequest, "unknown level %q", r.FormValue("level"))
+		return
+	}
+	var duration time.Duration
+	if str := r.FormValue("duration"); len(str) > 0 {
+		var err error
+		if duration, err = time.ParseDuration(str); err != nil || duration <= 0 || duration > MaxLevelDuration {
+			h.writeError(w, http.StatusBadRequest, "invalid duration %q, should be in (0, %v]", str, MaxLevelDuration)
+			return
+		}
+	}
+
+	var ok bool
+	if duration > 0 {
+		ok = h.log.SetLevelFor(tag, lvl, duration)
+	} else {
+		ok = h.log.SetLevel(tag, lvl)
+	}
+	if ok == false {
+		h.writeError(w, http.StatusNotFound, "filter %q not found", tag)
+		return
+	}
+	info, _ := h.getInfo(tag)
+	h.writeJSON(w, http.StatusOK, info)
+}
+
+func (h *levelHandler) getInfo(tag string) (LevelInfo, bool) {
+	filt := h.log.GetFilter(tag)
+	if filt == nil {
+		return LevelInfo{}, false
+	}
+	info := LevelInfo{
+		Tag:   tag,
+		Level: define.GetLevelName(filt.GetLevel()),
+	}
+	if at := filt.RevertAt(); at.IsZero() == false {
+		info.RevertAt = &at
+	}
+	return info, true
+}
+
+func (h *levelHandler) writeError(w http.ResponseWriter, code int, format string, args ...interface{}) {
+	h.writeJSON(w, code, map[string]string{
+		"error": fmt.Sprintf(format, args...),
+	})
+}
+
+func (h *levelHandler) writeJSON(w http.ResponseWriter, code int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(data)
+}
@@ -48,11 +48,10 @@ func (w *LogWriter) LogWrite(rec *define.LogRecord) {
 	w.rec <- rec
 }
 
-// Close 关闭
+// Close 关闭，缓冲中的日志输出完成后返回
 func (w *LogWriter) Close() {
-	w.stop <- true
-	<-w.stop
 	close(w.rec)
+	<-w.stop
 }
 
 // SetReportType 设置上报类型
@@ -109,9 +108,9 @@ func NewCatLogWriter(domain, group string) *LogWriter {
 					tp, data := rec.GetExtend()
 					switch tp {
 					case define.EXCatTransaction:
-						go w.dealTransaction(data)
+						go w.dealTransaction(data, rec.Fields)
 					case define.EXCatEvent:
-						go w.dealEvent(data)
+						go w.dealEvent(data, rec.Fields)
 					case define.EXCatError:
 						go w.dealError(data)
 					case define.EXCatMetricCount:
@@ -188,6 +187,14 @@ func (w *LogWriter) addMsgData(m *ccat.Message, v interface{}) {
 	}
 }
 
+func (w *LogWriter) addMsgFields(m *ccat.Message, fields define.Fields) {
+	if m != nil {
+		for _, field := range fields {
+			m.AddData(field.Key, field.ValueString())
+		}
+	}
+}
+
 func (w *LogWriter) setMsgStatus(m *ccat.Message, v interface{}) {
 	if m != nil && v != nil {
 		switch vl := v.(type) {
@@ -224,7 +231,7 @@ func (w *LogWriter) setMsgStatus(m *ccat.Message, v interface{}) {
 	}
 }
 
-func (w *LogWriter) dealTransaction(data []interface{}) {
+func (w *LogWriter) dealTransaction(data []interface{}, fields define.Fields) {
 	dtl := len(data)
 	if dtl > 0 {
 		t := cat.NewTransaction(w.rptgroup, w.getName(w.getArg(data, 0)))
@@ -234,11 +241,12 @@ func (w *LogWriter) dealTransaction(data []interface{}) {
 		if dtl > 2 {
 			w.setMsgStatus(&t.Message, w.getArg(data, 2))
 		}
+		w.addMsgFields(&t.Message, fields)
 		t.Complete()
 	}
 }
 
-func (w *LogWriter) dealEvent(data []interface{}) {
+func (w *LogWriter) dealEvent(data []interface{}, fields define.Fields) {
 	dtl := len(data)
 	if dtl > 0 {
 		t := cat.NewEvent(w.rptgroup, w.getName(w.getArg(data, 0)))
@@ -248,6 +256,7 @@ func (w *LogWriter) dealEvent(data []interface{}) {
 		if dtl > 2 {
 			w.addMsgData(&t.Message, w.getArg(data, 2))
 		}
+		w.addMsgFields(&t.Message, fields)
 		t.Complete()
 	}
 }
@@ -292,8 +301,19 @@ func (w *LogWriter) dealMetricDuration(data []interface{}) {
 
 // XMLToCatLogWriter xml创建cat日志输出
 func XMLToCatLogWriter(filename string, props []define.XMLProperty) (define.LogWriter, bool) {
+	return define.UnwrapCreater(XMLToCatLogWriterE)(filename, props)
+}
+
+// XMLToCatLogWriterE xml创建cat日志输出，返回详细错误
+func XMLToCatLogWriterE(filename string, props []define.XMLProperty) (define.LogWriter, error) {
+	return define.ParserCreater(XMLParseCatLogWriter)(filename, props)
+}
+
+// XMLParseCatLogWriter 解析cat日志输出的xml配置，返回打开函数
+func XMLParseCatLogWriter(filename string, props []define.XMLProperty) (define.WriterOpener, error) {
 	var (
 		domain, group string
+		cerr          = define.NewConfigError(filename)
 	)
 
 	// Parse properties
@@ -304,19 +324,26 @@ func XMLToCatLogWriter(filename string, props []define.XMLProperty) (define.LogW
 		case "group":
 			group = strings.Trim(prop.Value, " \r\n")
 		default:
-			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for file filter in %s\n", prop.Name, filename)
+			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for cat filter in %s\n", prop.Name, filename)
 		}
 	}
 
 	// Check properties
 	if len(domain) <= 0 {
-		fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Required property \"%s\" for file filter missing in %s\n", "domain", filename)
-		return nil, false
+		cerr.Addf("Required property \"%s\" for cat filter missing", "domain")
 	}
 	if len(group) <= 0 {
-		fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Required property \"%s\" for file filter missing in %s\n", "group", filename)
-		return nil, false
+		cerr.Addf("Required property \"%s\" for cat filter missing", "group")
+	}
+	if err := cerr.Err(); err != nil {
+		return nil, err
 	}
 
-	return NewCatLogWriter(domain, group), true
+	return func() (define.LogWriter, error) {
+		clw := NewCatLogWriter(domain, group)
+		if clw == nil {
+			return nil, fmt.Errorf("Could not init cat domain %q for cat filter", domain)
+		}
+		return clw, nil
+	}, nil
 }
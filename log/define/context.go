@@ -0,0 +1,93 @@
+package define
+
+import (
+	"context"
+	"sync"
+)
+
+// 常用context字段名
+const (
+	FieldTraceID = "trace_id"
+	FieldSpanID  = "span_id"
+	FieldUserID  = "user_id"
+)
+
+// ContextExtractor 从context中提取字段
+type ContextExtractor = func(ctx context.Context) Fields
+
+type ctxFieldsKey struct{}
+
+var (
+	ctxMutex      sync.RWMutex
+	ctxExtractors = []ContextExtractor{extractContextFields}
+)
+
+////////////////////////////////////////////////////////////////////////////////////
+
+// RegisterContextExtractor 注册context字段提取器
+func RegisterContextExtractor(ext ContextExtractor) {
+	if ext == nil {
+		return
+	}
+	ctxMutex.Lock()
+	defer ctxMutex.Unlock()
+	extractors := make([]ContextExtractor, 0, len(ctxExtractors)+1)
+	extractors = append(extractors, ctxExtractors...)
+	ctxExtractors = append(extractors, ext)
+}
+
+// RegisterContextKey 注册以 ctx.Value(key) 取值的字段
+func RegisterContextKey(name string, key interface{}) {
+	RegisterContextExtractor(func(ctx context.Context) Fields {
+		if val := ctx.Value(key); val != nil {
+			return Fields{Field{Key: name, Value: val}}
+		}
+		return nil
+	})
+}
+
+// ContextWithFields 在context中绑定字段
+func ContextWithFields(ctx context.Context, kvs ...interface{}) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	fields, _ := ctx.Value(ctxFieldsKey{}).(Fields)
+	return context.WithValue(ctx, ctxFieldsKey{}, fields.Append(MakeFields(kvs...)...))
+}
+
+// ContextWithTraceID 在context中绑定trace id
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return ContextWithFields(ctx, FieldTraceID, traceID)
+}
+
+// ContextWithSpanID 在context中绑定span id
+func ContextWithSpanID(ctx context.Context, spanID string) context.Context {
+	return ContextWithFields(ctx, FieldSpanID, spanID)
+}
+
+// ContextWithUserID 在context中绑定user id
+func ContextWithUserID(ctx context.Context, userID interface{}) context.Context {
+	return ContextWithFields(ctx, FieldUserID, userID)
+}
+
+// ContextFields 通过已注册的提取器获取context中的字段
+func ContextFields(ctx context.Context) Fields {
+	if ctx == nil {
+		return nil
+	}
+	ctxMutex.RLock()
+	extractors := ctxExtractors
+	ctxMutex.RUnlock()
+
+	var fields Fields
+	for _, ext := range extractors {
+		fields = append(fields, ext(ctx)...)
+	}
+	return fields
+}
+
+// extractContextFields 提取 ContextWithFields 绑定的字段
+func extractContextFields(ctx context.Context) Fields {
+	fields, _ := ctx.Value(ctxFieldsKey{}).(Fields)
+	return fields
+}
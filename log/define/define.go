@@ -1,6 +1,8 @@
 package define
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -47,13 +49,26 @@ var (
 	LevelStrings = []string{"", "fnst", "fine", "debug", "trace", "info", "warning", "error", "fatal", "report"}
 )
 
+// Field 结构化字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Fields 结构化字段集合，保持添加顺序
+type Fields []Field
+
 // LogRecord contains all of the pertinent information for each message
 type LogRecord struct {
 	Level   uint8     // The log level
 	Created time.Time // The time at which the log message was created (nanoseconds)
 	Source  string    // The message source
 	Message string    // The log message
+	Fields  Fields    `json:",omitempty"` // The structured key/value fields
 	Extend  []interface{}
+
+	File      string `json:",omitempty"` // The short file:line of the caller
+	Goroutine uint64 `json:",omitempty"` // The goroutine id, captured after EnableGoroutineID
 }
 
 // LogWriter 日志输出器
@@ -64,14 +79,49 @@ type LogWriter interface {
 	Close()
 }
 
+// Reopener 可重新打开输出目标的writer，配合外部日志切割工具使用
+type Reopener interface {
+	Reopen()
+}
+
 // WriterCreater 创建函数
 type WriterCreater = func(string, []XMLProperty) (LogWriter, bool)
 
-// Filter 日志过滤器
+// WriterCreaterE 创建函数，失败时返回详细错误
+type WriterCreaterE = func(string, []XMLProperty) (LogWriter, error)
+
+// WriterOpener 打开已校验配置的writer
+type WriterOpener = func() (LogWriter, error)
+
+// WriterParser 解析并校验配置，返回打开函数，解析时不打开输出目标
+type WriterParser = func(string, []XMLProperty) (WriterOpener, error)
+
+// Filter 日志过滤器，等级可在运行时修改
+// 原导出字段 Level 已移除，改用 GetLevel/SetLevel 以支持并发修改
 type Filter struct {
 	LogWriter
-	Level uint8
+
+	level   int32        // 当前等级
+	closed  int32        // 是否已关闭
+	sources atomic.Value // *sourceLevels 来源等级规则
+	drain   sync.RWMutex // 输出时持有读锁，关闭时获取写锁等待输出完成
+
+	mutex    sync.Mutex  // 临时等级锁
+	base     uint8       // 临时等级结束后恢复的等级
+	revert   *time.Timer // 临时等级恢复定时器
+	revertAt time.Time   // 临时等级恢复时间
+
+	writerConf string // 创建writer的配置，重新加载时配置不变则复用
+	levelConf  string // 等级配置，重新加载时配置不变则保留运行时修改的等级
 }
 
-// Logger 日志过滤器组合
-type Logger map[string]*Filter
+// Filters 日志过滤器集合
+type Filters map[string]*Filter
+
+// Logger 日志过滤器组合，可并发使用，运行时增删过滤器不影响正在输出的日志
+// With 派生的子logger与父logger共享过滤器，只额外携带绑定的字段
+// 须通过 NewLogger 创建，零值Logger没有过滤器，输出被忽略，增加过滤器无效
+type Logger struct {
+	set    *filterSet // 过滤器集合
+	fields Fields     // 绑定字段
+}
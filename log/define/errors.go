@@ -0,0 +1,66 @@
+package define
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// 错误定义
+var (
+	ErrCreateWriter = errors.New("could not create writer")
+)
+
+// ConfigError 配置错误，收集所有错误项
+type ConfigError struct {
+	Source string  // 配置来源
+	Errors []error // 错误列表
+}
+
+// NewConfigError 创建配置错误
+func NewConfigError(source string) *ConfigError {
+	return &ConfigError{
+		Source: source,
+	}
+}
+
+// Error 错误信息，每个错误项一行
+func (cerr *ConfigError) Error() string {
+	out := bytes.NewBuffer(make([]byte, 0, 128))
+	fmt.Fprintf(out, "configuration %s has %d error(s)", cerr.Source, len(cerr.Errors))
+	for _, err := range cerr.Errors {
+		out.WriteString("\n\t")
+		out.WriteString(err.Error())
+	}
+	return out.String()
+}
+
+// Addf 增加错误项
+func (cerr *ConfigError) Addf(format string, args ...interface{}) {
+	cerr.Errors = append(cerr.Errors, fmt.Errorf(format, args...))
+}
+
+// Merge 合并错误，配置错误展开为多项，prefix 非空时作为前缀
+func (cerr *ConfigError) Merge(prefix string, err error) {
+	if err == nil {
+		return
+	}
+	if other, ok := err.(*ConfigError); ok {
+		for _, item := range other.Errors {
+			cerr.Merge(prefix, item)
+		}
+		return
+	}
+	if len(prefix) > 0 {
+		err = fmt.Errorf("%s: %v", prefix, err)
+	}
+	cerr.Errors = append(cerr.Errors, err)
+}
+
+// Err 没有错误项时返回nil
+func (cerr *ConfigError) Err() error {
+	if cerr == nil || len(cerr.Errors) <= 0 {
+		return nil
+	}
+	return cerr
+}
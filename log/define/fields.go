@@ -0,0 +1,143 @@
+package define
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 字段定义
+const (
+	BadFieldKey = "!BADKEY" // 键值对缺少key时使用的key
+)
+
+////////////////////////////////////////////////////////////////////////////////////
+
+// MakeFields 键值对转换为字段集合
+// 参数可以是交替的 key, value，也可以直接是 Field / Fields
+func MakeFields(kvs ...interface{}) Fields {
+	if len(kvs) <= 0 {
+		return nil
+	}
+	fields := make(Fields, 0, (len(kvs)+1)/2)
+	for i := 0; i < len(kvs); i++ {
+		switch kv := kvs[i].(type) {
+		case Field:
+			fields = append(fields, kv)
+		case Fields:
+			fields = append(fields, kv...)
+		case string:
+			if i+1 < len(kvs) {
+				fields = append(fields, Field{Key: kv, Value: kvs[i+1]})
+				i++
+			} else {
+				fields = append(fields, Field{Key: BadFieldKey, Value: kv})
+			}
+		default:
+			fields = append(fields, Field{Key: BadFieldKey, Value: kv})
+		}
+	}
+	return fields
+}
+
+// ValueString 字段值字符串
+func (field Field) ValueString() string {
+	return fmt.Sprint(fieldValue(field.Value))
+}
+
+// JSONValue 用于json序列化的字段值
+func (field Field) JSONValue() interface{} {
+	return fieldValue(field.Value)
+}
+
+// Append 追加字段
+func (fields Fields) Append(others ...Field) Fields {
+	if len(others) <= 0 {
+		return fields
+	}
+	rel := make(Fields, 0, len(fields)+len(others))
+	rel = append(rel, fields...)
+	return append(rel, others...)
+}
+
+// Get 获取字段值
+func (fields Fields) Get(key string) (interface{}, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == key {
+			return fields[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// String 输出 key=value 格式
+func (fields Fields) String() string {
+	if len(fields) <= 0 {
+		return ""
+	}
+	out := bytes.NewBuffer(make([]byte, 0, 64))
+	for i, field := range fields {
+		if i > 0 {
+			out.WriteByte(' ')
+		}
+		out.WriteString(field.Key)
+		out.WriteByte('=')
+		out.WriteString(quoteFieldValue(field.ValueString()))
+	}
+	return out.String()
+}
+
+// MarshalJSON 按顺序输出为json对象
+func (fields Fields) MarshalJSON() ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, 64))
+	out.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(key)
+		out.WriteByte(':')
+		val, err := json.Marshal(field.JSONValue())
+		if err != nil {
+			// 无法序列化的值退化为字符串
+			val, _ = json.Marshal(fmt.Sprint(field.Value))
+		}
+		out.Write(val)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+// ToMap 转换为map
+func (fields Fields) ToMap() map[string]interface{} {
+	rel := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		rel[field.Key] = field.Value
+	}
+	return rel
+}
+
+// error 及延迟求值函数转为字符串
+func fieldValue(v interface{}) interface{} {
+	switch vl := v.(type) {
+	case error:
+		return vl.Error()
+	case func() string:
+		return vl()
+	}
+	return v
+}
+
+// 包含空白或特殊字符时加引号
+func quoteFieldValue(val string) string {
+	if len(val) <= 0 || strings.ContainsAny(val, " \t\r\n=\"") {
+		return strconv.Quote(val)
+	}
+	return val
+}
@@ -0,0 +1,268 @@
+package define
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////////
+
+// NewFilter 创建过滤器
+func NewFilter(writer LogWriter, lvl uint8) *Filter {
+	return &Filter{
+		LogWriter: writer,
+		level:     int32(lvl),
+	}
+}
+
+// GetLevel 获取当前等级
+func (filt *Filter) GetLevel() uint8 {
+	return uint8(atomic.LoadInt32(&filt.level))
+}
+
+// SetLevel 设置等级，同时取消临时等级
+func (filt *Filter) SetLevel(lvl uint8) {
+	filt.mutex.Lock()
+	defer filt.mutex.Unlock()
+	filt.stopRevert()
+	atomic.StoreInt32(&filt.level, int32(lvl))
+}
+
+// SetLevelFor 临时设置等级，duration 后恢复为临时设置前的等级
+func (filt *Filter) SetLevelFor(lvl uint8, duration time.Duration) {
+	if duration <= 0 {
+		filt.SetLevel(lvl)
+		return
+	}
+	filt.mutex.Lock()
+	defer filt.mutex.Unlock()
+	if filt.revert != nil {
+		filt.revert.Stop()
+	} else {
+		filt.base = filt.GetLevel()
+	}
+	atomic.StoreInt32(&filt.level, int32(lvl))
+
+	var timer *time.Timer
+	timer = time.AfterFunc(duration, func() {
+		filt.mutex.Lock()
+		defer filt.mutex.Unlock()
+		if filt.revert == timer {
+			atomic.StoreInt32(&filt.level, int32(filt.base))
+			filt.revert, filt.revertAt = nil, time.Time{}
+		}
+	})
+	filt.revert, filt.revertAt = timer, time.Now().Add(duration)
+}
+
+// RevertAt 临时等级恢复时间，没有临时等级时为零值
+func (filt *Filter) RevertAt() time.Time {
+	filt.mutex.Lock()
+	defer filt.mutex.Unlock()
+	return filt.revertAt
+}
+
+// stopRevert 取消临时等级，需持有锁
+func (filt *Filter) stopRevert() {
+	if filt.revert != nil {
+		filt.revert.Stop()
+		filt.revert, filt.revertAt = nil, time.Time{}
+	}
+}
+
+// SetConfig 记录创建过滤器的配置，由配置加载使用
+func (filt *Filter) SetConfig(writer, level string) {
+	filt.writerConf, filt.levelConf = writer, level
+}
+
+// Config 创建过滤器的配置，非配置加载创建的过滤器为空
+func (filt *Filter) Config() (writer, level string) {
+	return filt.writerConf, filt.levelConf
+}
+
+// LogWrite 输出日志，过滤器关闭后丢弃
+func (filt *Filter) LogWrite(rec *LogRecord) {
+	filt.drain.RLock()
+	if atomic.LoadInt32(&filt.closed) == 0 {
+		filt.LogWriter.LogWrite(rec)
+	}
+	filt.drain.RUnlock()
+}
+
+// Close 关闭，等待正在进行的输出完成后关闭writer，可重复调用
+func (filt *Filter) Close() {
+	if atomic.CompareAndSwapInt32(&filt.closed, 0, 1) == false {
+		return
+	}
+	filt.mutex.Lock()
+	filt.stopRevert()
+	filt.mutex.Unlock()
+	filt.drain.Lock()
+	filt.drain.Unlock()
+	if filt.LogWriter != nil {
+		filt.LogWriter.Close()
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////
+
+// filterSet 过滤器集合，写时复制，读取无锁
+type filterSet struct {
+	mutex   sync.Mutex   // 修改锁
+	filters atomic.Value // Filters 只读快照
+}
+
+func newFilterSet() *filterSet {
+	set := &filterSet{}
+	set.filters.Store(Filters{})
+	return set
+}
+
+// load 获取当前快照，快照不可修改
+func (set *filterSet) load() Filters {
+	if set == nil {
+		return nil
+	}
+	filters, _ := set.filters.Load().(Filters)
+	return filters
+}
+
+// update 复制当前快照，修改后整体替换，返回被替换掉的过滤器
+func (set *filterSet) update(fun func(Filters)) []*Filter {
+	if set == nil {
+		return nil
+	}
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	old := set.load()
+	filters := make(Filters, len(old))
+	for key, filt := range old {
+		filters[key] = filt
+	}
+	fun(filters)
+	set.filters.Store(filters)
+
+	var removed []*Filter
+	for key, filt := range old {
+		if filt != nil && filters[key] != filt {
+			removed = append(removed, filt)
+		}
+	}
+	return removed
+}
+
+////////////////////////////////////////////////////////////////////////////////////
+
+// Filters 当前过滤器快照，只读
+func (log Logger) Filters() Filters {
+	return log.set.load()
+}
+
+// GetFilter 获取过滤器
+func (log Logger) GetFilter(tag string) *Filter {
+	return log.set.load()[tag]
+}
+
+// Tags 过滤器标签列表
+func (log Logger) Tags() []string {
+	filters := log.set.load()
+	tags := make([]string, 0, len(filters))
+	for tag := range filters {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// GetLevel 获取过滤器等级，过滤器不存在时返回0
+func (log Logger) GetLevel(tag string) uint8 {
+	if filt := log.GetFilter(tag); filt != nil {
+		return filt.GetLevel()
+	}
+	return 0
+}
+
+// SetLevel 设置过滤器等级，过滤器不存在时返回false
+func (log Logger) SetLevel(tag string, lvl uint8) bool {
+	if filt := log.GetFilter(tag); filt != nil {
+		filt.SetLevel(lvl)
+		return true
+	}
+	return false
+}
+
+// SetLevelFor 临时设置过滤器等级，duration 后自动恢复，过滤器不存在时返回false
+func (log Logger) SetLevelFor(tag string, lvl uint8, duration time.Duration) bool {
+	if filt := log.GetFilter(tag); filt != nil {
+		filt.SetLevelFor(lvl, duration)
+		return true
+	}
+	return false
+}
+
+// AddFilter 增加过滤器，同名过滤器被替换并关闭，零值Logger不增加并关闭writer
+func (log Logger) AddFilter(tag string, writer LogWriter, lvl uint8) Logger {
+	if log.set == nil {
+		fmt.Fprintf(os.Stderr, "Logger: AddFilter(%q) on a zero Logger ignored, use NewLogger\n", tag)
+		if writer != nil {
+			writer.Close()
+		}
+		return log
+	}
+	closeFilters(log.set.update(func(filters Filters) {
+		filters[tag] = NewFilter(writer, lvl)
+	}))
+	return log
+}
+
+// ReplaceFilters 整体替换过滤器，不再使用的过滤器被关闭，零值Logger不替换并关闭传入的过滤器
+func (log Logger) ReplaceFilters(filters Filters) {
+	if log.set == nil {
+		fmt.Fprintf(os.Stderr, "Logger: ReplaceFilters on a zero Logger ignored, use NewLogger\n")
+		for _, filt := range filters {
+			if filt != nil {
+				filt.Close()
+			}
+		}
+		return
+	}
+	closeFilters(log.set.update(func(current Filters) {
+		for key := range current {
+			delete(current, key)
+		}
+		for key, filt := range filters {
+			if filt != nil {
+				current[key] = filt
+			}
+		}
+	}))
+}
+
+// RemoveFilter 移除并关闭过滤器
+func (log Logger) RemoveFilter(tag string) bool {
+	removed := log.set.update(func(filters Filters) {
+		delete(filters, tag)
+	})
+	closeFilters(removed)
+	return len(removed) > 0
+}
+
+// Close 关闭，子logger与父logger共享过滤器，会一并关闭
+func (log Logger) Close() {
+	closeFilters(log.set.update(func(filters Filters) {
+		for key := range filters {
+			delete(filters, key)
+		}
+	}))
+}
+
+func closeFilters(filters []*Filter) {
+	for _, filt := range filters {
+		filt.Close()
+	}
+}
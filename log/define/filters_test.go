@@ -0,0 +1,161 @@
+package define
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countWriter 记录输出数量，关闭后仍收到日志时记录错误
+type countWriter struct {
+	writes int64
+	late   int64
+	closed int32
+	delay  time.Duration
+	rptype uint8
+}
+
+func (w *countWriter) LogWrite(rec *LogRecord) {
+	if w.delay > 0 {
+		time.Sleep(w.delay)
+	}
+	if atomic.LoadInt32(&w.closed) != 0 {
+		atomic.AddInt64(&w.late, 1)
+	}
+	atomic.AddInt64(&w.writes, 1)
+}
+
+func (w *countWriter) Close() {
+	atomic.StoreInt32(&w.closed, 1)
+}
+
+func (w *countWriter) GetReportType() uint8 {
+	return w.rptype
+}
+
+func (w *countWriter) SetReportType(tp uint8) {
+	w.rptype = tp
+}
+
+func TestFilterCloseWaitsForWrites(t *testing.T) {
+	w := &countWriter{delay: 50 * time.Millisecond}
+	filt := NewFilter(w, DEBUG)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			filt.LogWrite(&LogRecord{Level: INFO, Message: "msg"})
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	filt.Close()
+	if atomic.LoadInt32(&w.closed) == 0 {
+		t.Fatal("writer not closed")
+	}
+	wg.Wait()
+	if late := atomic.LoadInt64(&w.late); late != 0 {
+		t.Fatalf("%d records written after close", late)
+	}
+
+	filt.LogWrite(&LogRecord{Level: INFO, Message: "dropped"})
+	if writes := atomic.LoadInt64(&w.writes); writes != 4 {
+		t.Fatalf("writes = %d, want 4", writes)
+	}
+	filt.Close()
+}
+
+func TestLoggerConcurrentFilterChanges(t *testing.T) {
+	log := NewLogger()
+	var mutex sync.Mutex
+	var writers []*countWriter
+	newWriter := func() LogWriter {
+		w := &countWriter{}
+		mutex.Lock()
+		writers = append(writers, w)
+		mutex.Unlock()
+		return w
+	}
+	log.AddFilter("stdout", newWriter(), DEBUG)
+
+	stop := make(chan bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child := log.With("worker", i)
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					child.Info("message %d", i)
+				}
+			}
+		}(i)
+	}
+
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for i := 0; time.Now().Before(deadline); i++ {
+		tag := fmt.Sprintf("tag%d", i%4)
+		switch i % 3 {
+		case 0:
+			log.AddFilter(tag, newWriter(), INFO)
+		case 1:
+			log.RemoveFilter(tag)
+		case 2:
+			log.ReplaceFilters(Filters{
+				"stdout": NewFilter(newWriter(), DEBUG),
+				tag:      NewFilter(newWriter(), INFO),
+			})
+		}
+		log.SetLevel("stdout", DEBUG+uint8(i%3))
+		time.Sleep(100 * time.Microsecond)
+	}
+	close(stop)
+	wg.Wait()
+	log.Close()
+
+	total := int64(0)
+	for _, w := range writers {
+		if atomic.LoadInt32(&w.closed) == 0 {
+			t.Fatal("writer not closed after Logger.Close")
+		}
+		if late := atomic.LoadInt64(&w.late); late != 0 {
+			t.Fatalf("%d records written after close", late)
+		}
+		total += atomic.LoadInt64(&w.writes)
+	}
+	if total <= 0 {
+		t.Fatal("no records written")
+	}
+}
+
+func TestZeroLogger(t *testing.T) {
+	// 零值Logger不输出，也不与其它零值Logger共享过滤器
+	var log, other Logger
+	w := &countWriter{}
+	log.AddFilter("zero", w, DEBUG)
+	if atomic.LoadInt32(&w.closed) == 0 {
+		t.Fatal("writer not closed when added to a zero Logger")
+	}
+	log.Info("zero value")
+	log.With("key", 1).Info("child")
+	if len(log.Tags()) != 0 || len(other.Tags()) != 0 || log.GetFilter("zero") != nil {
+		t.Fatalf("zero Logger has filters: %v %v", log.Tags(), other.Tags())
+	}
+	if writes := atomic.LoadInt64(&w.writes); writes != 0 {
+		t.Fatalf("writes = %d, want 0", writes)
+	}
+	replaced := &countWriter{}
+	other.ReplaceFilters(Filters{"zero": NewFilter(replaced, DEBUG)})
+	if atomic.LoadInt32(&replaced.closed) == 0 || len(other.Tags()) != 0 {
+		t.Fatal("zero Logger kept replaced filters")
+	}
+	log.RemoveFilter("zero")
+	log.Close()
+}
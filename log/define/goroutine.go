@@ -0,0 +1,33 @@
+package define
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+var (
+	captureGoroutine int32 // 是否记录goroutine id
+)
+
+// EnableGoroutineID 开启goroutine id记录，记录需要解析调用栈，有一定开销
+func EnableGoroutineID() {
+	atomic.StoreInt32(&captureGoroutine, 1)
+}
+
+// getGoroutineID 获取当前goroutine id，未开启时返回0
+func getGoroutineID() uint64 {
+	if atomic.LoadInt32(&captureGoroutine) == 0 {
+		return 0
+	}
+	var buf [64]byte
+	stack := buf[:runtime.Stack(buf[:], false)]
+	// goroutine 18 [running]:
+	stack = bytes.TrimPrefix(stack, []byte("goroutine "))
+	if index := bytes.IndexByte(stack, ' '); index > 0 {
+		stack = stack[:index]
+	}
+	id, _ := strconv.ParseUint(string(stack), 10, 64)
+	return id
+}
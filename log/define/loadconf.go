@@ -0,0 +1,124 @@
+package define
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 配置格式定义
+const (
+	ConfigXML  = "xml"
+	ConfigYAML = "yaml"
+	ConfigJSON = "json"
+)
+
+// ConfigFilter yaml/json 过滤器配置，与 XMLFilter 对应
+type ConfigFilter struct {
+	Enabled    interface{}            `yaml:"enabled" json:"enabled"`
+	Tag        string                 `yaml:"tag" json:"tag"`
+	Level      string                 `yaml:"level" json:"level"`
+	Type       string                 `yaml:"type" json:"type"`
+	RptType    string                 `yaml:"report" json:"report"`
+	Sources    map[string]string      `yaml:"sources" json:"sources"`
+	Properties map[string]interface{} `yaml:"properties" json:"properties"`
+}
+
+// LoggerConfig yaml/json logger配置，与 XMLLoggerConfig 对应
+type LoggerConfig struct {
+	Filters []ConfigFilter `yaml:"filters" json:"filters"`
+}
+
+////////////////////////////////////////////////////////////////////////////////////
+
+// GetConfigFormat 根据文件扩展名判断配置格式，默认xml
+func GetConfigFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return ConfigYAML
+	case ".json":
+		return ConfigJSON
+	default:
+		return ConfigXML
+	}
+}
+
+// ParseConfig 按格式解析配置，统一转换为 XMLLoggerConfig
+func ParseConfig(format string, contents []byte) (*XMLLoggerConfig, error) {
+	switch strings.ToLower(format) {
+	case ConfigXML:
+		xc := new(XMLLoggerConfig)
+		if err := xml.Unmarshal(contents, xc); err != nil {
+			return nil, err
+		}
+		return xc, nil
+	case ConfigYAML, "yml":
+		conf := new(LoggerConfig)
+		if err := yaml.Unmarshal(contents, conf); err != nil {
+			return nil, err
+		}
+		return conf.ToXML(), nil
+	case ConfigJSON:
+		conf := new(LoggerConfig)
+		decoder := json.NewDecoder(bytes.NewReader(contents))
+		decoder.UseNumber()
+		if err := decoder.Decode(conf); err != nil {
+			return nil, err
+		}
+		return conf.ToXML(), nil
+	default:
+		return nil, fmt.Errorf("unknown configuration format %q", format)
+	}
+}
+
+// ToXML 转换为 XMLLoggerConfig，属性按名称排序
+func (conf *LoggerConfig) ToXML() *XMLLoggerConfig {
+	xc := &XMLLoggerConfig{
+		Filter: make([]XMLFilter, 0, len(conf.Filters)),
+	}
+	for _, filt := range conf.Filters {
+		xmlfilt := XMLFilter{
+			Tag:     filt.Tag,
+			Level:   filt.Level,
+			Type:    filt.Type,
+			RptType: filt.RptType,
+		}
+		if filt.Enabled != nil {
+			xmlfilt.Enabled = fmt.Sprint(filt.Enabled)
+		}
+		sources := make([]string, 0, len(filt.Sources))
+		for source := range filt.Sources {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+		for _, source := range sources {
+			xmlfilt.Source = append(xmlfilt.Source, XMLSource{
+				Level:  filt.Sources[source],
+				Source: source,
+			})
+		}
+		names := make([]string, 0, len(filt.Properties))
+		for name := range filt.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			value := ""
+			if val := filt.Properties[name]; val != nil {
+				value = fmt.Sprint(val)
+			}
+			xmlfilt.Property = append(xmlfilt.Property, XMLProperty{
+				Name:  name,
+				Value: value,
+			})
+		}
+		xc.Filter = append(xc.Filter, xmlfilt)
+	}
+	return xc
+}
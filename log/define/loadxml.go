@@ -1,11 +1,22 @@
 package define
 
+import (
+	"fmt"
+	"os"
+)
+
 // XMLProperty property属性
 type XMLProperty struct {
 	Name  string `xml:"name,attr"`
 	Value string `xml:",chardata"`
 }
 
+// XMLSource source来源等级
+type XMLSource struct {
+	Level  string `xml:"level,attr"`
+	Source string `xml:",chardata"`
+}
+
 // XMLFilter filter过滤器
 type XMLFilter struct {
 	Enabled  string        `xml:"enabled,attr"`
@@ -13,6 +24,7 @@ type XMLFilter struct {
 	Level    string        `xml:"level"`
 	Type     string        `xml:"type"`
 	RptType  string        `xml:"report"`
+	Source   []XMLSource   `xml:"source"`
 	Property []XMLProperty `xml:"property"`
 }
 
@@ -24,7 +36,7 @@ type XMLLoggerConfig struct {
 // GetLevel 等级
 func GetLevel(lvl string) uint8 {
 	switch lvl {
-	case "FINEST", "finest":
+	case "FINEST", "finest", "FNST", "fnst":
 		return FINEST
 	case "FINE", "fine":
 		return FINE
@@ -47,6 +59,14 @@ func GetLevel(lvl string) uint8 {
 	}
 }
 
+// GetLevelName 等级名称
+func GetLevelName(lvl uint8) string {
+	if int(lvl) < len(LevelStrings) {
+		return LevelStrings[lvl]
+	}
+	return ""
+}
+
 // GetReportType 上报类型
 func GetReportType(rptp string) uint8 {
 	switch rptp {
@@ -58,3 +78,62 @@ func GetReportType(rptp string) uint8 {
 		return 0
 	}
 }
+
+// WrapCreater 将 WriterCreater 转换为 WriterCreaterE
+func WrapCreater(fun WriterCreater) WriterCreaterE {
+	if fun == nil {
+		return nil
+	}
+	return func(filename string, props []XMLProperty) (LogWriter, error) {
+		writer, good := fun(filename, props)
+		if good == false || writer == nil {
+			return nil, ErrCreateWriter
+		}
+		return writer, nil
+	}
+}
+
+// UnwrapCreater 将 WriterCreaterE 转换为 WriterCreater，错误输出到标准错误
+func UnwrapCreater(fun WriterCreaterE) WriterCreater {
+	if fun == nil {
+		return nil
+	}
+	return func(filename string, props []XMLProperty) (LogWriter, bool) {
+		writer, err := fun(filename, props)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: %v\n", err)
+			return nil, false
+		}
+		return writer, true
+	}
+}
+
+// ParserCreater 将 WriterParser 转换为 WriterCreaterE，校验通过后立即打开
+func ParserCreater(fun WriterParser) WriterCreaterE {
+	if fun == nil {
+		return nil
+	}
+	return func(filename string, props []XMLProperty) (LogWriter, error) {
+		open, err := fun(filename, props)
+		if err != nil {
+			return nil, err
+		}
+		writer, err := open()
+		if err == nil && writer == nil {
+			err = ErrCreateWriter
+		}
+		return writer, err
+	}
+}
+
+// CreaterParser 将 WriterCreaterE 转换为 WriterParser，配置在打开时才校验
+func CreaterParser(fun WriterCreaterE) WriterParser {
+	if fun == nil {
+		return nil
+	}
+	return func(filename string, props []XMLProperty) (WriterOpener, error) {
+		return func() (LogWriter, error) {
+			return fun(filename, props)
+		}, nil
+	}
+}
@@ -1,7 +1,9 @@
 package define
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
@@ -32,26 +34,43 @@ func (record LogRecord) GetExtend() (tp uint8, data []interface{}) {
 
 ////////////////////////////////////////////////////////////////////////////////////
 
-// Close 关闭
-func (log Logger) Close() {
-	for key, filt := range log {
-		if filt != nil {
-			filt.Close()
-		}
-		delete(log, key)
+// NewLogger 创建
+func NewLogger() Logger {
+	return Logger{
+		set: newFilterSet(),
 	}
 }
 
-// AddFilter 增加过滤器
-func (log Logger) AddFilter(tag string, writer LogWriter, lvl uint8) Logger {
-	log[tag] = &Filter{writer, lvl}
-	return log
+// With 派生绑定字段的子logger，共享父logger的过滤器
+func (log Logger) With(kvs ...interface{}) Logger {
+	return Logger{
+		set:    log.set,
+		fields: log.makeFields(kvs...),
+	}
+}
+
+// WithContext 派生绑定context字段的子logger
+func (log Logger) WithContext(ctx context.Context) Logger {
+	return log.With(ContextFields(ctx))
+}
+
+// Fields 绑定字段
+func (log Logger) Fields() Fields {
+	return log.fields
+}
+
+// makeFields 合并绑定字段与本次字段
+func (log Logger) makeFields(kvs ...interface{}) Fields {
+	if len(kvs) <= 0 {
+		return log.fields
+	}
+	return log.fields.Append(MakeFields(kvs...)...)
 }
 
 // checkSkip 检查
 func (log Logger) checkSkip(lvl uint8) bool {
-	for _, filt := range log {
-		if filt != nil && lvl >= filt.Level {
+	for _, filt := range log.set.load() {
+		if filt != nil && lvl >= filt.minLevel() {
 			return false
 		}
 	}
@@ -63,7 +82,7 @@ func (log Logger) checkReport(rptp uint8) bool {
 	if rptp <= 0 {
 		return true
 	}
-	for _, filt := range log {
+	for _, filt := range log.set.load() {
 		if filt != nil && rptp == filt.GetReportType() {
 			return true
 		}
@@ -74,8 +93,8 @@ func (log Logger) checkReport(rptp uint8) bool {
 // dispatchLog 分发日志
 func (log Logger) dispatchLog(rec *LogRecord, rptp uint8) {
 	if rec != nil {
-		for _, filt := range log {
-			if filt != nil && rec.Level >= filt.Level {
+		for _, filt := range log.set.load() {
+			if filt != nil && rec.Level >= filt.LevelFor(rec.Source) {
 				if rptp > 0 && rptp != filt.GetReportType() {
 					continue
 				}
@@ -85,13 +104,27 @@ func (log Logger) dispatchLog(rec *LogRecord, rptp uint8) {
 	}
 }
 
-// getRunCaller 获取调用地址
-func getRunCaller(skip int) string {
-	pc, _, lineno, ok := runtime.Caller(skip + 1)
+// getRunCaller 获取调用地址及文件位置
+func getRunCaller(skip int) (string, string) {
+	pc, file, lineno, ok := runtime.Caller(skip + 1)
 	if ok {
-		return fmt.Sprintf("%s:%d", runtime.FuncForPC(pc).Name(), lineno)
+		return fmt.Sprintf("%s:%d", runtime.FuncForPC(pc).Name(), lineno), fmt.Sprintf("%s:%d", filepath.Base(file), lineno)
+	}
+	return "", ""
+}
+
+// newRecord 创建日志记录，skip 与 getRunCaller 一致
+func newRecord(skip int, lvl uint8, msg string, fields Fields) *LogRecord {
+	source, file := getRunCaller(skip + 1)
+	return &LogRecord{
+		Level:     lvl,
+		Created:   time.Now(),
+		Source:    source,
+		File:      file,
+		Goroutine: getGoroutineID(),
+		Message:   msg,
+		Fields:    fields,
 	}
-	return ""
 }
 
 // Send a formatted log message internally
@@ -103,12 +136,15 @@ func (log Logger) intLogf(skip int, lvl uint8, format string, args ...interface{
 	if len(args) > 0 {
 		msg = fmt.Sprintf(format, args...)
 	}
-	log.dispatchLog(&LogRecord{
-		Level:   lvl,
-		Created: time.Now(),
-		Source:  getRunCaller(skip + 1),
-		Message: msg,
-	}, 0)
+	log.dispatchLog(newRecord(skip+1, lvl, msg, log.fields), 0)
+}
+
+// Send a structured log message internally
+func (log Logger) intLogkv(skip int, lvl uint8, msg string, kvs ...interface{}) {
+	if log.checkSkip(lvl) == true {
+		return
+	}
+	log.dispatchLog(newRecord(skip+1, lvl, msg, log.makeFields(kvs...)), 0)
 }
 
 // Log Send a log message with manual level, source, and message.
@@ -117,10 +153,12 @@ func (log Logger) Log(lvl uint8, source, message string) {
 		return
 	}
 	log.dispatchLog(&LogRecord{
-		Level:   lvl,
-		Created: time.Now(),
-		Source:  source,
-		Message: message,
+		Level:     lvl,
+		Created:   time.Now(),
+		Source:    source,
+		Goroutine: getGoroutineID(),
+		Message:   message,
+		Fields:    log.fields,
 	}, 0)
 }
 
@@ -132,16 +170,35 @@ func (log Logger) Logf(skip int, lvl uint8, format string, args ...interface{})
 	log.intLogf(skip, lvl, format, args...)
 }
 
+// LogKV 结构化日志输出，kvs 为交替的 key, value
+func (log Logger) LogKV(skip int, lvl uint8, msg string, kvs ...interface{}) {
+	if skip <= 0 {
+		skip = 1
+	}
+	log.intLogkv(skip, lvl, msg, kvs...)
+}
+
+// LogCtx 携带context日志输出，context中的字段追加到日志记录
+func (log Logger) LogCtx(ctx context.Context, skip int, lvl uint8, arg interface{}, args ...interface{}) {
+	if log.checkSkip(lvl) == true {
+		return
+	}
+	if skip <= 0 {
+		skip = 1
+	}
+	msg := log.getArg(arg, len(args))
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	log.dispatchLog(newRecord(skip, lvl, msg, log.fields.Append(ContextFields(ctx)...)), 0)
+}
+
 // LogReport 上报
 func (log Logger) LogReport(skip int, rptp, extp uint8, exdt ...interface{}) {
 	if log.checkSkip(REPORT) == true || log.checkReport(rptp) == false {
 		return
 	}
-	record := &LogRecord{
-		Level:   REPORT,
-		Created: time.Now(),
-		Source:  getRunCaller(skip + 1),
-	}
+	record := newRecord(skip+1, REPORT, "", log.fields)
 	if extp > 0 {
 		record.SetExtend(extp, exdt)
 	} else if len(exdt) > 0 {
@@ -213,6 +270,86 @@ func (log Logger) Fatal(arg interface{}, args ...interface{}) {
 	log.LogCmm(FATAL, arg, args...)
 }
 
+// FinestKV 最好结构化log
+func (log Logger) FinestKV(msg string, kvs ...interface{}) {
+	log.LogKV(2, FINEST, msg, kvs...)
+}
+
+// FineKV 好结构化log
+func (log Logger) FineKV(msg string, kvs ...interface{}) {
+	log.LogKV(2, FINE, msg, kvs...)
+}
+
+// DebugKV 调试结构化log
+func (log Logger) DebugKV(msg string, kvs ...interface{}) {
+	log.LogKV(2, DEBUG, msg, kvs...)
+}
+
+// TraceKV 追踪结构化log
+func (log Logger) TraceKV(msg string, kvs ...interface{}) {
+	log.LogKV(2, TRACE, msg, kvs...)
+}
+
+// InfoKV 信息结构化log
+func (log Logger) InfoKV(msg string, kvs ...interface{}) {
+	log.LogKV(2, INFO, msg, kvs...)
+}
+
+// WarnKV 警告结构化log
+func (log Logger) WarnKV(msg string, kvs ...interface{}) {
+	log.LogKV(2, WARNING, msg, kvs...)
+}
+
+// ErrorKV 错误结构化log
+func (log Logger) ErrorKV(msg string, kvs ...interface{}) {
+	log.LogKV(2, ERROR, msg, kvs...)
+}
+
+// FatalKV 致命结构化log
+func (log Logger) FatalKV(msg string, kvs ...interface{}) {
+	log.LogKV(2, FATAL, msg, kvs...)
+}
+
+// FinestCtx 最好 context log
+func (log Logger) FinestCtx(ctx context.Context, arg interface{}, args ...interface{}) {
+	log.LogCtx(ctx, 2, FINEST, arg, args...)
+}
+
+// FineCtx 好 context log
+func (log Logger) FineCtx(ctx context.Context, arg interface{}, args ...interface{}) {
+	log.LogCtx(ctx, 2, FINE, arg, args...)
+}
+
+// DebugCtx 调试 context log
+func (log Logger) DebugCtx(ctx context.Context, arg interface{}, args ...interface{}) {
+	log.LogCtx(ctx, 2, DEBUG, arg, args...)
+}
+
+// TraceCtx 追踪 context log
+func (log Logger) TraceCtx(ctx context.Context, arg interface{}, args ...interface{}) {
+	log.LogCtx(ctx, 2, TRACE, arg, args...)
+}
+
+// InfoCtx 信息 context log
+func (log Logger) InfoCtx(ctx context.Context, arg interface{}, args ...interface{}) {
+	log.LogCtx(ctx, 2, INFO, arg, args...)
+}
+
+// WarnCtx 警告 context log
+func (log Logger) WarnCtx(ctx context.Context, arg interface{}, args ...interface{}) {
+	log.LogCtx(ctx, 2, WARNING, arg, args...)
+}
+
+// ErrorCtx 错误 context log
+func (log Logger) ErrorCtx(ctx context.Context, arg interface{}, args ...interface{}) {
+	log.LogCtx(ctx, 2, ERROR, arg, args...)
+}
+
+// FatalCtx 致命 context log
+func (log Logger) FatalCtx(ctx context.Context, arg interface{}, args ...interface{}) {
+	log.LogCtx(ctx, 2, FATAL, arg, args...)
+}
+
 // Report 上报log
 func (log Logger) Report(rptp uint8, arg interface{}, args ...interface{}) {
 	log.Reports(4, rptp, arg, args...)
@@ -0,0 +1,29 @@
+package define
+
+import "sync/atomic"
+
+// Reopen 重新打开writer的输出目标，writer不支持或过滤器已关闭时返回false
+func (filt *Filter) Reopen() bool {
+	reopener, ok := filt.LogWriter.(Reopener)
+	if ok == false {
+		return false
+	}
+	filt.drain.RLock()
+	defer filt.drain.RUnlock()
+	if atomic.LoadInt32(&filt.closed) != 0 {
+		return false
+	}
+	reopener.Reopen()
+	return true
+}
+
+// Reopen 重新打开所有支持的writer，返回重新打开的数量
+func (log Logger) Reopen() int {
+	count := 0
+	for _, filt := range log.set.load() {
+		if filt.Reopen() {
+			count++
+		}
+	}
+	return count
+}
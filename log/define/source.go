@@ -0,0 +1,106 @@
+package define
+
+import (
+	"sort"
+	"strings"
+)
+
+// SourceLevel 按调用来源设置的等级
+// Source 为包路径或函数名前缀，如 github.com/x/payments 或 github.com/x/payments.(*Service).Pay
+type SourceLevel struct {
+	Source string
+	Level  uint8
+}
+
+// sourceLevels 来源等级规则，按前缀长度倒序，只读
+type sourceLevels struct {
+	rules []SourceLevel
+	min   uint8 // 规则中的最低等级
+}
+
+////////////////////////////////////////////////////////////////////////////////////
+
+// SetSourceLevels 设置来源等级规则，替换原有规则，最长前缀优先
+func (filt *Filter) SetSourceLevels(rules []SourceLevel) {
+	if len(rules) <= 0 {
+		filt.sources.Store(&sourceLevels{})
+		return
+	}
+	sorted := make([]SourceLevel, 0, len(rules))
+	for _, rule := range rules {
+		if len(rule.Source) > 0 && rule.Level > 0 {
+			sorted = append(sorted, rule)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Source) > len(sorted[j].Source)
+	})
+	levels := &sourceLevels{rules: sorted}
+	for _, rule := range sorted {
+		if levels.min == 0 || rule.Level < levels.min {
+			levels.min = rule.Level
+		}
+	}
+	filt.sources.Store(levels)
+}
+
+// SourceLevels 来源等级规则
+func (filt *Filter) SourceLevels() []SourceLevel {
+	levels := filt.loadSources()
+	if levels == nil {
+		return nil
+	}
+	return append([]SourceLevel(nil), levels.rules...)
+}
+
+// LevelFor 指定来源生效的等级
+func (filt *Filter) LevelFor(source string) uint8 {
+	if levels := filt.loadSources(); levels != nil {
+		for _, rule := range levels.rules {
+			if matchSource(source, rule.Source) {
+				return rule.Level
+			}
+		}
+	}
+	return filt.GetLevel()
+}
+
+// minLevel 过滤器及来源规则中的最低等级
+func (filt *Filter) minLevel() uint8 {
+	lvl := filt.GetLevel()
+	if levels := filt.loadSources(); levels != nil && levels.min > 0 && levels.min < lvl {
+		lvl = levels.min
+	}
+	return lvl
+}
+
+func (filt *Filter) loadSources() *sourceLevels {
+	levels, _ := filt.sources.Load().(*sourceLevels)
+	return levels
+}
+
+// matchSource 前缀匹配，前缀之后须为 . / : 或结尾，避免 pay 匹配 payments
+func matchSource(source, prefix string) bool {
+	if strings.HasPrefix(source, prefix) == false {
+		return false
+	}
+	if len(source) == len(prefix) {
+		return true
+	}
+	switch source[len(prefix)] {
+	case '.', '/', ':':
+		return true
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////////////////
+
+// SetSourceLevels 设置过滤器的来源等级规则，过滤器不存在时返回false
+func (log Logger) SetSourceLevels(tag string, rules []SourceLevel) bool {
+	if filt := log.GetFilter(tag); filt != nil {
+		filt.SetSourceLevels(rules)
+		return true
+	}
+	return false
+}
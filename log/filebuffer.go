@@ -0,0 +1,179 @@
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+// 落盘策略
+const (
+	SyncNever    int32 = iota // 不主动fsync，由系统决定
+	SyncInterval              // 每个刷新周期fsync
+	SyncRecord                // 每条日志fsync
+)
+
+// 常量定义
+const (
+	DefaultFlushInterval = time.Second // 默认缓冲刷新间隔
+)
+
+// SetBufferSize 设置写缓冲大小，0 关闭缓冲每条日志直接写文件，返回时已生效
+// 缓冲按刷新间隔写入文件，ERROR及以上等级的日志立即写入
+func (w *FileLogWriter) SetBufferSize(size int) *FileLogWriter {
+	if size < 0 {
+		size = 0
+	}
+	atomic.StoreInt64(&w.bufsize, int64(size))
+	w.notifyReset()
+	return w
+}
+
+// SetFlushInterval 设置缓冲刷新间隔，<= 0 时使用默认间隔
+func (w *FileLogWriter) SetFlushInterval(interval time.Duration) *FileLogWriter {
+	atomic.StoreInt64(&w.flushInterval, int64(interval))
+	w.notifyReset()
+	return w
+}
+
+// SetSyncPolicy 设置落盘策略 SyncNever/SyncInterval/SyncRecord
+func (w *FileLogWriter) SetSyncPolicy(policy int32) *FileLogWriter {
+	atomic.StoreInt32(&w.syncPolicy, policy)
+	w.notifyReset()
+	return w
+}
+
+// GetSyncPolicy 获取落盘策略
+func (w *FileLogWriter) GetSyncPolicy() int32 {
+	return atomic.LoadInt32(&w.syncPolicy)
+}
+
+// notifyReset 通知输出协程配置已变化，输出协程应用后返回，writer已关闭时直接返回
+func (w *FileLogWriter) notifyReset() {
+	done := make(chan bool)
+	select {
+	case w.reset <- done:
+		<-done
+	case <-w.closed:
+	}
+}
+
+// applyBuffer 按设置创建或关闭缓冲，在输出协程中调用
+func (w *FileLogWriter) applyBuffer() {
+	size := int(atomic.LoadInt64(&w.bufsize))
+	if w.buf != nil && w.buf.Size() == size {
+		return
+	}
+	if w.buf != nil {
+		w.buf.Flush()
+		w.buf = nil
+	}
+	if size > 0 && w.file != nil {
+		w.buf = bufio.NewWriterSize(w.file, size)
+	}
+}
+
+// resetFlush 重置缓冲刷新定时器，无缓冲且不按周期落盘时停止
+func (w *FileLogWriter) resetFlush(timer *time.Timer) {
+	if timer.Stop() == false {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	if w.buf == nil && w.GetSyncPolicy() != SyncInterval {
+		return
+	}
+	interval := time.Duration(atomic.LoadInt64(&w.flushInterval))
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	timer.Reset(interval)
+}
+
+// writeString 写入当前文件，有缓冲时写入缓冲
+// 缓冲剩余空间不足时先刷新，保证每条日志一次写入文件，多进程追加时不会在行中间交错
+func (w *FileLogWriter) writeString(str string) (int, error) {
+	if w.buf != nil {
+		if w.buf.Available() < len(str) && w.buf.Buffered() > 0 {
+			if err := w.buf.Flush(); err != nil {
+				return 0, err
+			}
+		}
+		if len(str) <= w.buf.Available() {
+			return w.buf.WriteString(str)
+		}
+	}
+	if w.file == nil {
+		return 0, os.ErrInvalid
+	}
+	return io.WriteString(w.file, str)
+}
+
+// afterWrite 按等级及落盘策略刷新缓冲
+func (w *FileLogWriter) afterWrite(rec *Record) error {
+	if w.GetSyncPolicy() == SyncRecord {
+		return w.flush(true)
+	}
+	if rec.Level >= define.ERROR {
+		return w.flush(false)
+	}
+	return nil
+}
+
+// flush 缓冲写入文件，sync 为true时同时fsync
+func (w *FileLogWriter) flush(sync bool) error {
+	if w.buf != nil {
+		if err := w.buf.Flush(); err != nil {
+			return err
+		}
+	}
+	if sync && w.file != nil {
+		return w.file.Sync()
+	}
+	return nil
+}
+
+// openFile 切换到新打开的文件
+func (w *FileLogWriter) openFile(fd *os.File) {
+	registerFile(fd)
+	w.file = fd
+	if w.buf != nil {
+		w.buf.Reset(fd)
+	} else {
+		w.applyBuffer()
+	}
+}
+
+// closeFile 写入文件尾，刷新缓冲后关闭当前文件
+func (w *FileLogWriter) closeFile() {
+	if w.file == nil {
+		return
+	}
+	w.writeString(FormatLogRecord(w.trailer, &Record{Created: time.Now()}))
+	if err := w.flush(w.GetSyncPolicy() != SyncNever); err != nil {
+		fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
+	}
+	releaseFile(w.file)
+	w.file.Close()
+	w.file = nil
+}
+
+// strToSyncPolicy 解析落盘策略 never/interval/record
+func strToSyncPolicy(str string) (int32, error) {
+	switch strings.ToLower(str) {
+	case "", "never", "none":
+		return SyncNever, nil
+	case "interval":
+		return SyncInterval, nil
+	case "record", "always":
+		return SyncRecord, nil
+	}
+	return SyncNever, fmt.Errorf("unknown sync policy, use never, interval or record")
+}
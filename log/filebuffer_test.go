@@ -0,0 +1,76 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+func newBufferedWriter(t *testing.T, interval time.Duration) (*FileLogWriter, string) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "log4go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir += "/"
+	w := NewFileLogWriter(dir, "app.log", false)
+	if w == nil {
+		t.Fatal("NewFileLogWriter failed")
+	}
+	w.SetFormat("%M").SetBufferSize(4096).SetFlushInterval(interval)
+	return w, dir
+}
+
+// waitWritten 等待已输出的日志被输出协程处理
+func waitWritten(t *testing.T, w *FileLogWriter) {
+	t.Helper()
+	waitFor(t, "records received", func() bool { return len(w.rec) == 0 })
+	// 输出协程处理完当前日志后才会响应
+	w.notifyReset()
+}
+
+func fileContent(t *testing.T, name string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBufferFlushOnError(t *testing.T) {
+	w, dir := newBufferedWriter(t, time.Hour)
+	defer os.RemoveAll(dir)
+	name := dir + "app.log"
+
+	w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: "buffered"})
+	waitWritten(t, w)
+	if content := fileContent(t, name); content != "" {
+		t.Fatalf("INFO record written before flush: %q", content)
+	}
+
+	w.LogWrite(&Record{Level: define.ERROR, Created: time.Now(), Message: "failed"})
+	waitWritten(t, w)
+	if content := fileContent(t, name); content != "buffered\nfailed\n" {
+		t.Fatalf("buffer not flushed on ERROR: %q", content)
+	}
+
+	w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: "closing"})
+	w.Close()
+	if content := fileContent(t, name); content != "buffered\nfailed\nclosing\n" {
+		t.Fatalf("buffer not flushed on close: %q", content)
+	}
+}
+
+func TestBufferFlushInterval(t *testing.T) {
+	w, dir := newBufferedWriter(t, 20*time.Millisecond)
+	defer os.RemoveAll(dir)
+	name := dir + "app.log"
+
+	w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: "buffered"})
+	waitFor(t, "flush interval", func() bool { return fileContent(t, name) == "buffered\n" })
+	w.Close()
+}
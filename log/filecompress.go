@@ -0,0 +1,192 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 常量定义
+const (
+	compressSuffix     = ".gz"                  // 压缩文件后缀
+	tempSuffix         = ".tmp"                 // 压缩中的临时文件后缀
+	compressRetryDelay = 100 * time.Millisecond // 文件仍被打开时重试压缩的间隔
+)
+
+// openFiles 本进程中writer当前打开的文件，仍被打开的文件不压缩
+// 如重新加载配置时新writer已转存，旧writer关闭前仍在写入改名后的文件
+var openFiles = struct {
+	sync.Mutex
+	files map[*os.File]os.FileInfo
+}{files: make(map[*os.File]os.FileInfo)}
+
+// registerFile 记录打开的文件
+func registerFile(fd *os.File) {
+	info, err := fd.Stat()
+	if err != nil {
+		return
+	}
+	openFiles.Lock()
+	openFiles.files[fd] = info
+	openFiles.Unlock()
+}
+
+// releaseFile 文件关闭前移除记录
+func releaseFile(fd *os.File) {
+	openFiles.Lock()
+	delete(openFiles.files, fd)
+	openFiles.Unlock()
+}
+
+// fileInUse 文件是否仍被本进程的writer打开
+func fileInUse(name string) bool {
+	info, err := os.Stat(name)
+	if err != nil {
+		return false
+	}
+	openFiles.Lock()
+	defer openFiles.Unlock()
+	for _, opened := range openFiles.files {
+		if os.SameFile(info, opened) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCompress 设置是否gzip压缩转存文件，压缩在后台进行，不阻塞日志输出
+// 开启时会清理上次异常退出残留的临时文件，并补压未压缩的转存文件
+func (w *FileLogWriter) SetCompress(compress bool) *FileLogWriter {
+	w.compress = compress
+	if compress {
+		w.recoverCompress()
+	}
+	return w
+}
+
+// compressBackup 后台压缩转存文件，同一输出器的压缩串行执行
+// 文件仍被本进程其它writer打开时等待其关闭，输出器关闭时放弃，下次开启压缩时补压
+// 多进程模式下写入前会在转存锁内检查文件是否已被转存，改名后的文件不会再被写入
+func (w *FileLogWriter) compressBackup(name string) {
+	if w.compress == false || strings.HasSuffix(name, compressSuffix) {
+		return
+	}
+	w.compressWait.Add(1)
+	go func() {
+		defer w.compressWait.Done()
+		for fileInUse(w.dir + name) {
+			select {
+			case <-w.closed:
+				if fileInUse(w.dir + name) {
+					return
+				}
+			case <-time.After(compressRetryDelay):
+			}
+		}
+		w.compressMutex.Lock()
+		defer w.compressMutex.Unlock()
+		if err := compressFile(w.dir + name); err != nil {
+			fmt.Fprintf(os.Stderr, "FileLogWriter(%q): Compress: %s\n", name, err)
+		}
+	}()
+}
+
+// recoverCompress 清理残留的临时文件，压缩未压缩的转存文件
+func (w *FileLogWriter) recoverCompress() {
+	backups, err := w.listBackups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "FileLogWriter(%q): Compress: %s\n", w.filename, err)
+		return
+	}
+	exists := make(map[string]bool, len(backups))
+	for _, backup := range backups {
+		exists[backup.name] = true
+	}
+	for _, backup := range backups {
+		if strings.HasSuffix(backup.name, compressSuffix) {
+			os.Remove(w.dir + backup.name + tempSuffix)
+		} else if exists[backup.name+compressSuffix] == false {
+			w.compressBackup(backup.name)
+		}
+	}
+}
+
+// compressFile 压缩文件为 .gz，先写临时文件并落盘，再改名并删除原文件，压缩文件沿用原文件的修改时间
+// 任一步骤中断时原文件保持不变，残留的临时文件在下次压缩时覆盖
+// 临时文件加建议锁，多进程共享文件时同一文件只由一个进程压缩
+func compressFile(src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer in.Close()
+
+	dst := src + compressSuffix
+	tmp := dst + tempSuffix
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE, 0660)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if lockFile(out, false) != nil {
+		// 其它进程正在压缩
+		return nil
+	}
+
+	// 原文件已被其它进程压缩或被保留策略删除
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		os.Remove(tmp)
+		return nil
+	}
+	err = out.Truncate(0)
+	if err == nil {
+		gz := gzip.NewWriter(out)
+		_, err = io.Copy(gz, in)
+		if err == nil {
+			err = gz.Close()
+		}
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if err == nil {
+		// 保留原文件的修改时间，保留策略按修改时间排序及计算时长
+		var info os.FileInfo
+		if info, err = in.Stat(); err == nil {
+			err = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+		}
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// 压缩期间原文件已被保留策略删除
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		os.Remove(tmp)
+		return nil
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// strToCompress 解析压缩方式，支持 gzip/true 与 none/false
+func strToCompress(str string) (bool, error) {
+	switch strings.ToLower(str) {
+	case "gzip", "true":
+		return true, nil
+	case "", "none", "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("unsupported compression, only gzip is available")
+}
@@ -0,0 +1,107 @@
+package log
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+func TestCompressWaitsForOpenWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log4go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir += "/"
+
+	const N = 1000
+	old := NewFileLogWriter(dir, "app.log", true)
+	old.SetFormat("%M")
+	for i := 0; i < N; i++ {
+		old.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: "old"})
+	}
+
+	// 与重新加载配置相同：新writer转存旧writer仍在写入的文件
+	w := NewFileLogWriter(dir, "app.log", true)
+	w.SetFormat("%M").SetCompress(true)
+	for i := 0; i < N; i++ {
+		old.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: "old"})
+		w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: "new"})
+	}
+	time.Sleep(3 * compressRetryDelay)
+	if _, err := os.Stat(dir + "app.log.001" + compressSuffix); err == nil {
+		t.Fatal("compressed a file still open by another writer")
+	}
+	old.Close()
+	w.Rotate()
+	w.Close()
+
+	if lines, _ := countLines(t, dir, "app.log"); lines != 3*N {
+		t.Fatalf("got %d lines, want %d", lines, 3*N)
+	}
+	if _, err := os.Stat(dir + "app.log.001" + compressSuffix); err != nil {
+		t.Fatalf("backup not compressed after writer closed: %v", err)
+	}
+}
+
+func TestCompressKeepsModTime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log4go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir += "/"
+
+	// 3个超过保留时长的转存文件及2个较新的转存文件
+	ages := []time.Duration{240 * time.Hour, 216 * time.Hour, 192 * time.Hour, 2 * time.Hour, time.Hour}
+	modTimes := make(map[string]time.Time, len(ages))
+	for i, age := range ages {
+		name := fmt.Sprintf("app.log.%03d", i+1)
+		if err := ioutil.WriteFile(dir+name, []byte(name+"\n"), 0660); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-age).Truncate(time.Second)
+		if err := os.Chtimes(dir+name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		modTimes[name+compressSuffix] = mtime
+	}
+	w := NewFileLogWriter(dir, "app.log", true)
+	w.SetFormat("%M").SetCompress(true)
+	w.Close()
+	for name, mtime := range modTimes {
+		info, err := os.Stat(dir + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.ModTime().Equal(mtime) == false {
+			t.Fatalf("%s: modification time %v, want %v", name, info.ModTime(), mtime)
+		}
+	}
+
+	// 保留策略按原修改时间删除过期及较旧的转存文件
+	// 新writer转存上次的空文件，加上本次转存共2个新文件
+	w = NewFileLogWriter(dir, "app.log", true)
+	w.SetFormat("%M").SetMaxAge(7 * 24 * time.Hour).SetMaxBackups(3)
+	w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: "current"})
+	w.Rotate()
+	w.Close()
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed []string
+	for _, info := range files {
+		if strings.HasSuffix(info.Name(), compressSuffix) {
+			compressed = append(compressed, info.Name())
+		}
+	}
+	if len(files) != 4 || len(compressed) != 1 || compressed[0] != "app.log.005"+compressSuffix {
+		t.Fatalf("unexpected files after retention: %d files, compressed %v", len(files), compressed)
+	}
+}
@@ -0,0 +1,46 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SetSymlink 设置指向当前文件的符号链接名，位于文件目录下，空字符串关闭
+// 文件名带时间时可用固定名称跟踪当前文件，如 app.log -> app-2026-10-17.log，每次转存原子更新
+func (w *FileLogWriter) SetSymlink(name string) *FileLogWriter {
+	w.symlink.Store(name)
+	w.notifyReset()
+	return w
+}
+
+// GetSymlink 获取符号链接名
+func (w *FileLogWriter) GetSymlink() string {
+	name, _ := w.symlink.Load().(string)
+	return name
+}
+
+// updateSymlink 将符号链接指向当前文件，先创建临时链接再改名覆盖
+func (w *FileLogWriter) updateSymlink() error {
+	name := w.GetSymlink()
+	if len(name) <= 0 || name == w.filename {
+		return nil
+	}
+	link := w.dir + name
+	if target, err := os.Readlink(link); err == nil && target == filepath.Base(w.filename) {
+		return nil
+	}
+	if info, err := os.Lstat(link); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("Symlink: %s exists and is not a symlink", link)
+	}
+	tmp := link + tempSuffix
+	os.Remove(tmp)
+	if err := os.Symlink(filepath.Base(w.filename), tmp); err != nil {
+		return fmt.Errorf("Symlink: %v", err)
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("Symlink: %v", err)
+	}
+	return nil
+}
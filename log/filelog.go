@@ -1,11 +1,14 @@
 package log
 
 import (
-	"bytes"
+	"bufio"
 	"fmt"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lerryxiao/log4go/log/define"
@@ -13,9 +16,10 @@ import (
 
 // FileLogWriter 文件日志输出
 type FileLogWriter struct {
-	rec  chan *Record
-	rot  chan bool
-	stop chan bool
+	rec    chan *Record
+	rot    chan bool
+	reopen chan bool
+	stop   chan bool
 
 	// The opened file
 	dir            string
@@ -24,7 +28,7 @@ type FileLogWriter struct {
 	file           *os.File
 
 	// The logging format
-	format string
+	formatter formatterValue
 
 	// File header/trailer
 	header, trailer string
@@ -37,13 +41,39 @@ type FileLogWriter struct {
 	maxsize        int
 	maxsizeCursize int
 
-	// Rotate daily
-	daily         bool
-	dailyOpendate int
+	// Rotate at interval, aligned to local time
+	interval int64
+	rotateAt time.Time
+	reset    chan chan bool
 
 	// Keep old logfiles (.001, .002, etc)
 	rotate bool
 
+	// Retention of rotated files
+	maxbackups int
+	maxage     time.Duration
+	maxtotal   int64
+
+	// Multi-process coordination by advisory lock
+	shared bool
+	lock   *os.File
+	locked bool
+
+	// Stable symlink to the current file
+	symlink atomic.Value
+
+	// Buffered writes, owned by the writer goroutine
+	buf           *bufio.Writer
+	bufsize       int64
+	flushInterval int64
+	syncPolicy    int32
+
+	// Compress rotated files in background
+	compress      bool
+	compressMutex sync.Mutex
+	compressWait  sync.WaitGroup
+	closed        chan bool
+
 	rptype uint8
 }
 
@@ -52,11 +82,12 @@ func (w *FileLogWriter) LogWrite(rec *Record) {
 	w.rec <- rec
 }
 
-// Close 关闭
+// Close 关闭，缓冲中的日志输出完成且后台压缩结束后返回，等待其它writer释放的压缩被放弃
 func (w *FileLogWriter) Close() {
-	w.stop <- true
-	<-w.stop
 	close(w.rec)
+	<-w.stop
+	close(w.closed)
+	w.compressWait.Wait()
 }
 
 // SetReportType 设置上报类型
@@ -71,16 +102,28 @@ func (w *FileLogWriter) GetReportType() uint8 {
 
 // NewFileLogWriter 创建文件输出节点
 func NewFileLogWriter(dir, fname string, rotate bool) *FileLogWriter {
+	return newFileLogWriter(dir, fname, rotate, false)
+}
+
+func newFileLogWriter(dir, fname string, rotate, shared bool) *FileLogWriter {
+	if shared && flockSupported == false {
+		fmt.Fprintf(os.Stderr, "FileLogWriter(%q): shared mode is not supported on %s\n", fname, runtime.GOOS)
+		return nil
+	}
 	w := &FileLogWriter{
 		rec:            make(chan *Record, define.LogBufferLength),
 		rot:            make(chan bool),
+		reopen:         make(chan bool),
 		stop:           make(chan bool),
+		reset:          make(chan chan bool),
+		closed:         make(chan bool),
 		dir:            dir,
 		filename:       fname,
 		filenameFormat: fname,
-		format:         "[%D %T] [%L] (%S) %M",
 		rotate:         rotate,
+		shared:         shared,
 	}
+	w.formatter.Store(NewRecordFormatter(FormatDefault))
 	//check dir is exist,
 	if len(strings.TrimSpace(dir)) > 0 {
 		_, err := os.Stat(dir)
@@ -101,11 +144,15 @@ func NewFileLogWriter(dir, fname string, rotate bool) *FileLogWriter {
 	}
 
 	go func() {
+		timer := time.NewTimer(time.Hour)
+		timer.Stop()
+		flush := time.NewTimer(time.Hour)
+		flush.Stop()
 		defer func() {
-			if w.file != nil {
-				fmt.Fprint(w.file, FormatLogRecord(w.trailer, &Record{Created: time.Now()}))
-				w.file.Close()
-			}
+			timer.Stop()
+			flush.Stop()
+			w.closeFile()
+			w.closeShared()
 			w.stop <- true
 		}()
 
@@ -119,34 +166,52 @@ func NewFileLogWriter(dir, fname string, rotate bool) *FileLogWriter {
 				{
 					if err := w.intRotate(); err != nil {
 						fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
-						continue
 					}
+					w.resetTimer(timer)
+				}
+			case <-w.reopen:
+				{
+					if err := w.intReopen(); err != nil {
+						fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
+					}
+				}
+			case done := <-w.reset:
+				{
+					w.rotateAt = nextRotateTime(time.Now(), w.GetRotateInterval())
+					w.resetTimer(timer)
+					w.applyBuffer()
+					w.resetFlush(flush)
+					if err := w.updateSymlink(); err != nil {
+						fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
+					}
+					close(done)
+				}
+			case <-flush.C:
+				{
+					if err := w.flush(w.GetSyncPolicy() == SyncInterval); err != nil {
+						fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
+					}
+					w.resetFlush(flush)
+				}
+			case <-timer.C:
+				{
+					if err := w.intRotate(); err != nil {
+						fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
+					}
+					w.resetTimer(timer)
 				}
 			case rec, ok := <-w.rec:
 				{
 					if !ok {
 						return
 					}
-					now := time.Now()
-					if (w.maxlines > 0 && w.maxlinesCurlines >= w.maxlines) ||
-						(w.maxsize > 0 && w.maxsizeCursize >= w.maxsize) ||
-						(w.daily && now.Day() != w.dailyOpendate) {
-						if err := w.intRotate(); err != nil {
-							fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
-							continue
+					if w.shared {
+						if w.writeShared(rec, timer) == false {
+							return
 						}
-					}
-
-					// Perform the write
-					n, err := fmt.Fprint(w.file, FormatLogRecord(w.format, rec))
-					if err != nil {
-						fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
 						continue
 					}
-
-					// Update the counts
-					w.maxlinesCurlines++
-					w.maxsizeCursize += n
+					w.writeRecord(rec, timer)
 				}
 			}
 		}
@@ -156,33 +221,100 @@ func NewFileLogWriter(dir, fname string, rotate bool) *FileLogWriter {
 	return w
 }
 
+// writeRecord 按需转存后写入一条日志
+func (w *FileLogWriter) writeRecord(rec *Record, timer *time.Timer) {
+	if (w.maxlines > 0 && w.maxlinesCurlines >= w.maxlines) ||
+		(w.maxsize > 0 && w.maxsizeCursize >= w.maxsize) ||
+		(w.rotateAt.IsZero() == false && time.Now().Before(w.rotateAt) == false) {
+		err := w.intRotate()
+		w.resetTimer(timer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
+			return
+		}
+	}
+
+	// Perform the write
+	n, err := w.writeString(w.formatter.Load().Format(rec))
+	if err == nil {
+		err = w.afterWrite(rec)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
+		return
+	}
+
+	// Update the counts
+	w.maxlinesCurlines++
+	w.maxsizeCursize += n
+}
+
 // Rotate 设置转存
 func (w *FileLogWriter) Rotate() {
 	w.rot <- true
 }
 
+// Reopen 关闭并按原路径重新打开当前文件，不改名
+// 用于外部logrotate以create方式切割后，改为写入新创建的文件
+func (w *FileLogWriter) Reopen() {
+	w.reopen <- true
+}
+
+// intReopen 重新打开当前文件
+func (w *FileLogWriter) intReopen() error {
+	w.closeFile()
+	fd, err := os.OpenFile(w.dir+w.filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0660)
+	if err != nil {
+		return fmt.Errorf("Reopen: %v", err)
+	}
+	w.openFile(fd)
+
+	w.maxlinesCurlines = 0
+	w.maxsizeCursize = 0
+	if info, err := fd.Stat(); err == nil && info.Size() > 0 {
+		w.maxsizeCursize = int(info.Size())
+	} else {
+		w.writeString(FormatLogRecord(w.header, &Record{Created: time.Now()}))
+	}
+	return nil
+}
+
+// resetTimer 按下一个转存时刻重置定时器
+func (w *FileLogWriter) resetTimer(timer *time.Timer) {
+	if timer.Stop() == false {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	if w.rotateAt.IsZero() == false {
+		timer.Reset(time.Until(w.rotateAt))
+	}
+}
+
 // 开始转存
 func (w *FileLogWriter) intRotate() error {
-	// Close any log file that may be open
-	if w.file != nil {
-		fmt.Fprint(w.file, FormatLogRecord(w.trailer, &Record{Created: time.Now()}))
-		w.file.Close()
-	}
-	pieces := bytes.Split([]byte(w.filenameFormat), []byte{'%'})
-	out := bytes.NewBuffer(make([]byte, 0, 64))
-	for _, p := range pieces {
-		if p[0] == 'D' {
-			out.WriteString(time.Now().Format("2006-01-02"))
-			if len(p) > 1 {
-				out.Write(p[1:])
+	// In shared mode only the process still holding the file renames it
+	owned := true
+	if w.shared {
+		if w.locked == false {
+			if err := w.lockShared(); err != nil {
+				return err
 			}
-		} else {
-			out.Write(p)
+			defer w.unlockShared()
 		}
+		owned = w.ownsFile()
+	}
+
+	// Close any log file that may be open
+	closed := ""
+	if w.file != nil {
+		w.closeFile()
+		closed = w.filename
 	}
-	w.filename = out.String()
+	w.filename = expandFilename(w.filenameFormat, time.Now())
 	// If we are keeping log files, move it to the next available number
-	if w.rotate {
+	if w.rotate && owned && (w.shared == false || closed == w.filename) {
 		_, err := os.Lstat(w.dir + w.filename)
 		if err == nil { // file exists
 			// Find the next available number
@@ -191,6 +323,9 @@ func (w *FileLogWriter) intRotate() error {
 			for ; err == nil && num <= 999; num++ {
 				fname = w.filename + fmt.Sprintf(".%03d", num)
 				_, err = os.Lstat(w.dir + fname)
+				if err != nil {
+					_, err = os.Lstat(w.dir + fname + compressSuffix)
+				}
 			}
 			// return error if the last file checked still existed
 			if err == nil {
@@ -202,7 +337,11 @@ func (w *FileLogWriter) intRotate() error {
 			if err != nil {
 				return fmt.Errorf("Rotate: %v", err)
 			}
+			w.compressBackup(fname)
 		}
+	} else if owned && len(closed) > 0 && closed != w.filename {
+		// The date in filename changed, the closed file is a backup now
+		w.compressBackup(closed)
 	}
 
 	// Open the log file
@@ -211,24 +350,50 @@ func (w *FileLogWriter) intRotate() error {
 		return err
 	}
 
-	w.file = fd
+	w.openFile(fd)
 
 	now := time.Now()
-	fmt.Fprint(w.file, FormatLogRecord(w.header, &Record{Created: now}))
+	size := int64(0)
+	if w.shared {
+		if info, err := fd.Stat(); err == nil {
+			size = info.Size()
+		}
+	}
+	if size == 0 {
+		w.writeString(FormatLogRecord(w.header, &Record{Created: now}))
+	}
 
-	// Set the daily open date to the current date
-	w.dailyOpendate = now.Day()
+	// Schedule the next time based rotation
+	w.rotateAt = nextRotateTime(now, w.GetRotateInterval())
 
 	// initialize rotation values
 	w.maxlinesCurlines = 0
-	w.maxsizeCursize = 0
+	w.maxsizeCursize = int(size)
+
+	// Point the symlink to the new file, failure does not stop logging
+	if err := w.updateSymlink(); err != nil {
+		fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
+	}
+
+	// Remove expired backups, failure does not stop logging
+	if err := w.applyRetention(); err != nil {
+		fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
+	}
 
 	return nil
 }
 
-// SetFormat 设置输出格式
+// SetFormat 设置输出格式，json 为json行格式
 func (w *FileLogWriter) SetFormat(format string) *FileLogWriter {
-	w.format = format
+	w.formatter.Store(NewRecordFormatter(format))
+	return w
+}
+
+// SetFormatter 设置格式化器
+func (w *FileLogWriter) SetFormatter(formatter RecordFormatter) *FileLogWriter {
+	if formatter != nil {
+		w.formatter.Store(formatter)
+	}
 	return w
 }
 
@@ -258,8 +423,10 @@ func (w *FileLogWriter) SetRotateSize(maxsize int) *FileLogWriter {
 // SetRotateDaily 设置每天转存
 func (w *FileLogWriter) SetRotateDaily(daily bool) *FileLogWriter {
 	//fmt.Fprintf(os.Stderr, "FileLogWriter.SetRotateDaily: %v\n", daily)
-	w.daily = daily
-	return w
+	if daily {
+		return w.SetRotateInterval(RotateDaily)
+	}
+	return w.SetRotateInterval(0)
 }
 
 // SetRotate 设置已转存
@@ -270,7 +437,10 @@ func (w *FileLogWriter) SetRotate(rotate bool) *FileLogWriter {
 }
 
 // Parse a number with K/M/G suffixes based on thousands (1000) or 2^10 (1024)
-func strToNumSuffix(str string, mult int) int {
+func strToNumSuffix(str string, mult int) (int, error) {
+	if len(str) <= 0 {
+		return 0, nil
+	}
 	num := 1
 	if len(str) > 1 {
 		switch str[len(str)-1] {
@@ -285,33 +455,61 @@ func strToNumSuffix(str string, mult int) int {
 			str = str[0 : len(str)-1]
 		}
 	}
-	parsed, _ := strconv.Atoi(str)
-	return parsed * num
+	parsed, err := strconv.Atoi(str)
+	return parsed * num, err
 }
 
 // XMLToFileLogWriter xml创建文件日志输出
 func XMLToFileLogWriter(filename string, props []define.XMLProperty) (Writer, bool) {
+	return define.UnwrapCreater(XMLToFileLogWriterE)(filename, props)
+}
+
+// XMLToFileLogWriterE xml创建文件日志输出，返回详细错误
+func XMLToFileLogWriterE(filename string, props []define.XMLProperty) (Writer, error) {
+	return define.ParserCreater(XMLParseFileLogWriter)(filename, props)
+}
+
+// XMLParseFileLogWriter 解析文件日志输出的xml配置，返回打开函数，解析时不打开文件
+func XMLParseFileLogWriter(filename string, props []define.XMLProperty) (define.WriterOpener, error) {
 	file := ""
-	format := "[%D %T] [%L] (%S) %M"
+	format := FormatDefault
+	jsonkeys := ""
 	maxlines := 0
 	maxsize := 0
-	daily := false
+	var interval time.Duration
 	rotate := false
 	dir := ""
+	maxbackups := 0
+	var maxage time.Duration
+	maxtotal := 0
+	compress := false
+	bufsize := 0
+	var flushInterval time.Duration
+	syncPolicy := SyncNever
+	symlink := ""
+	shared := false
+	cerr := define.NewConfigError(filename)
 
 	// Parse properties
 	for _, prop := range props {
+		var err error
 		switch prop.Name {
 		case "filename":
 			file = strings.Trim(prop.Value, " \r\n")
 		case "format":
 			format = strings.Trim(prop.Value, " \r\n")
+		case "jsonkeys":
+			jsonkeys = strings.Trim(prop.Value, " \r\n")
 		case "maxlines":
-			maxlines = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1000)
+			maxlines, err = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1000)
 		case "maxsize":
-			maxsize = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1024)
+			maxsize, err = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1024)
 		case "daily":
-			daily = strings.Trim(prop.Value, " \r\n") != "false"
+			if strings.Trim(prop.Value, " \r\n") != "false" {
+				interval = RotateDaily
+			}
+		case "interval":
+			interval, err = strToInterval(strings.Trim(prop.Value, " \r\n"))
 		case "dir":
 			dir = strings.Trim(prop.Value, " \r\n")
 			if !strings.HasSuffix(dir, "/") {
@@ -319,55 +517,127 @@ func XMLToFileLogWriter(filename string, props []define.XMLProperty) (Writer, bo
 			}
 		case "rotate":
 			rotate = strings.Trim(prop.Value, " \r\n") != "false"
+		case "maxbackups":
+			maxbackups, err = strconv.Atoi(strings.Trim(prop.Value, " \r\n"))
+		case "maxage":
+			maxage, err = strToDuration(strings.Trim(prop.Value, " \r\n"))
+		case "maxtotalsize":
+			maxtotal, err = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1024)
+		case "compress":
+			compress, err = strToCompress(strings.Trim(prop.Value, " \r\n"))
+		case "buffersize":
+			bufsize, err = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1024)
+		case "flushinterval":
+			flushInterval, err = time.ParseDuration(strings.Trim(prop.Value, " \r\n"))
+		case "sync":
+			syncPolicy, err = strToSyncPolicy(strings.Trim(prop.Value, " \r\n"))
+		case "symlink":
+			symlink = strings.Trim(prop.Value, " \r\n")
+		case "shared":
+			shared = strings.Trim(prop.Value, " \r\n") != "false"
 		default:
 			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for file filter in %s\n", prop.Name, filename)
 		}
+		if err != nil {
+			cerr.Addf("Invalid value %q for property \"%s\" of file filter: %v", prop.Value, prop.Name, err)
+		}
 	}
 
 	// Check properties
 	if len(file) == 0 {
-		fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Required property \"%s\" for file filter missing in %s\n", "filename", filename)
-		return nil, false
+		cerr.Addf("Required property \"%s\" for file filter missing", "filename")
+	}
+	if shared && flockSupported == false {
+		cerr.Addf("Property \"%s\" of file filter is not supported on %s", "shared", runtime.GOOS)
+	}
+	if err := cerr.Err(); err != nil {
+		return nil, err
 	}
 
-	flw := NewFileLogWriter(dir, file, rotate)
-	flw.SetFormat(format)
-	flw.SetRotateLines(maxlines)
-	flw.SetRotateSize(maxsize)
-	flw.SetRotateDaily(daily)
-	return flw, true
+	return func() (Writer, error) {
+		flw := newFileLogWriter(dir, file, rotate, shared)
+		if flw == nil {
+			return nil, fmt.Errorf("Could not open file %q for file filter", dir+file)
+		}
+		if format == FormatJSON {
+			flw.SetFormatter(NewJSONFormatter().SetKeys(jsonkeys))
+		} else {
+			flw.SetFormat(format)
+		}
+		flw.SetRotateLines(maxlines)
+		flw.SetRotateSize(maxsize)
+		flw.SetRotateInterval(interval)
+		flw.SetMaxBackups(maxbackups)
+		flw.SetMaxAge(maxage)
+		flw.SetMaxTotalSize(int64(maxtotal))
+		flw.SetCompress(compress)
+		flw.SetBufferSize(bufsize)
+		flw.SetFlushInterval(flushInterval)
+		flw.SetSyncPolicy(syncPolicy)
+		flw.SetSymlink(symlink)
+		return flw, nil
+	}, nil
 }
 
 // NewXMLLogWriter 创建xml日志输出
 func NewXMLLogWriter(dir, fname string, rotate bool) *FileLogWriter {
-	return NewFileLogWriter(dir, fname, rotate).SetFormat(
+	flw := NewFileLogWriter(dir, fname, rotate)
+	if flw == nil {
+		return nil
+	}
+	return flw.SetFormat(
 		`	<record level="%L">
 		<timestamp>%D %T</timestamp>
 		<source>%S</source>
 		<message>%M</message>
+		<fields>%F</fields>
 	</record>`).SetHeadFoot("<log created=\"%D %T\">", "</log>")
 }
 
 // XMLToXMLLogWriter xml创建xml日志输出
 func XMLToXMLLogWriter(filename string, props []define.XMLProperty) (Writer, bool) {
+	return define.UnwrapCreater(XMLToXMLLogWriterE)(filename, props)
+}
+
+// XMLToXMLLogWriterE xml创建xml日志输出，返回详细错误
+func XMLToXMLLogWriterE(filename string, props []define.XMLProperty) (Writer, error) {
+	return define.ParserCreater(XMLParseXMLLogWriter)(filename, props)
+}
+
+// XMLParseXMLLogWriter 解析xml日志输出的xml配置，返回打开函数，解析时不打开文件
+func XMLParseXMLLogWriter(filename string, props []define.XMLProperty) (define.WriterOpener, error) {
 	file := ""
 	maxrecords := 0
 	maxsize := 0
-	daily := false
+	var interval time.Duration
 	rotate := false
 	dir := ""
+	maxbackups := 0
+	var maxage time.Duration
+	maxtotal := 0
+	compress := false
+	bufsize := 0
+	var flushInterval time.Duration
+	syncPolicy := SyncNever
+	symlink := ""
+	cerr := define.NewConfigError(filename)
 
 	// Parse properties
 	for _, prop := range props {
+		var err error
 		switch prop.Name {
 		case "filename":
 			file = strings.Trim(prop.Value, " \r\n")
 		case "maxrecords":
-			maxrecords = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1000)
+			maxrecords, err = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1000)
 		case "maxsize":
-			maxsize = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1024)
+			maxsize, err = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1024)
 		case "daily":
-			daily = strings.Trim(prop.Value, " \r\n") != "false"
+			if strings.Trim(prop.Value, " \r\n") != "false" {
+				interval = RotateDaily
+			}
+		case "interval":
+			interval, err = strToInterval(strings.Trim(prop.Value, " \r\n"))
 		case "dir":
 			dir = strings.Trim(prop.Value, " \r\n")
 			if !strings.HasSuffix(dir, "/") {
@@ -375,20 +645,54 @@ func XMLToXMLLogWriter(filename string, props []define.XMLProperty) (Writer, boo
 			}
 		case "rotate":
 			rotate = strings.Trim(prop.Value, " \r\n") != "false"
+		case "maxbackups":
+			maxbackups, err = strconv.Atoi(strings.Trim(prop.Value, " \r\n"))
+		case "maxage":
+			maxage, err = strToDuration(strings.Trim(prop.Value, " \r\n"))
+		case "maxtotalsize":
+			maxtotal, err = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1024)
+		case "compress":
+			compress, err = strToCompress(strings.Trim(prop.Value, " \r\n"))
+		case "buffersize":
+			bufsize, err = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1024)
+		case "flushinterval":
+			flushInterval, err = time.ParseDuration(strings.Trim(prop.Value, " \r\n"))
+		case "sync":
+			syncPolicy, err = strToSyncPolicy(strings.Trim(prop.Value, " \r\n"))
+		case "symlink":
+			symlink = strings.Trim(prop.Value, " \r\n")
 		default:
 			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for xml filter in %s\n", prop.Name, filename)
 		}
+		if err != nil {
+			cerr.Addf("Invalid value %q for property \"%s\" of xml filter: %v", prop.Value, prop.Name, err)
+		}
 	}
 
 	// Check properties
 	if len(file) == 0 {
-		fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Required property \"%s\" for xml filter missing in %s\n", "filename", filename)
-		return nil, false
+		cerr.Addf("Required property \"%s\" for xml filter missing", "filename")
+	}
+	if err := cerr.Err(); err != nil {
+		return nil, err
 	}
 
-	xlw := NewXMLLogWriter(dir, file, rotate)
-	xlw.SetRotateLines(maxrecords)
-	xlw.SetRotateSize(maxsize)
-	xlw.SetRotateDaily(daily)
-	return xlw, true
+	return func() (Writer, error) {
+		xlw := NewXMLLogWriter(dir, file, rotate)
+		if xlw == nil {
+			return nil, fmt.Errorf("Could not open file %q for xml filter", dir+file)
+		}
+		xlw.SetRotateLines(maxrecords)
+		xlw.SetRotateSize(maxsize)
+		xlw.SetRotateInterval(interval)
+		xlw.SetMaxBackups(maxbackups)
+		xlw.SetMaxAge(maxage)
+		xlw.SetMaxTotalSize(int64(maxtotal))
+		xlw.SetCompress(compress)
+		xlw.SetBufferSize(bufsize)
+		xlw.SetFlushInterval(flushInterval)
+		xlw.SetSyncPolicy(syncPolicy)
+		xlw.SetSymlink(symlink)
+		return xlw, nil
+	}, nil
 }
@@ -0,0 +1,121 @@
+package log
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backupFile 转存的日志文件
+type backupFile struct {
+	name    string
+	modTime time.Time
+	size    int64
+}
+
+// SetMaxBackups 设置保留的转存文件数量，0 不限制
+func (w *FileLogWriter) SetMaxBackups(maxbackups int) *FileLogWriter {
+	w.maxbackups = maxbackups
+	return w
+}
+
+// SetMaxAge 设置转存文件最长保留时间，0 不限制
+func (w *FileLogWriter) SetMaxAge(maxage time.Duration) *FileLogWriter {
+	w.maxage = maxage
+	return w
+}
+
+// SetMaxTotalSize 设置当前文件与转存文件的总大小上限，从新到旧累计，超出上限的文件及更旧的文件全部删除，0 不限制
+func (w *FileLogWriter) SetMaxTotalSize(maxtotal int64) *FileLogWriter {
+	w.maxtotal = maxtotal
+	return w
+}
+
+// backupPattern 匹配本输出器产生的文件：文件名模板中的时间可变，可带 .NNN 序号及 .gz 后缀
+func (w *FileLogWriter) backupPattern() *regexp.Regexp {
+	return regexp.MustCompile("^" + filenamePattern(w.filenameFormat) + `(\.\d{3})?(\.gz)?$`)
+}
+
+// listBackups 列出转存文件，不含当前文件，按修改时间从新到旧排序
+func (w *FileLogWriter) listBackups() ([]backupFile, error) {
+	dir := w.dir
+	if len(dir) <= 0 {
+		dir = "."
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	pattern := w.backupPattern()
+	backups := make([]backupFile, 0, len(infos))
+	for _, info := range infos {
+		if info.Mode().IsRegular() == false || info.Name() == w.filename || pattern.MatchString(info.Name()) == false {
+			continue
+		}
+		backups = append(backups, backupFile{
+			name:    info.Name(),
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		})
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].modTime.After(backups[j].modTime)
+	})
+	return backups, nil
+}
+
+// applyRetention 按保留策略删除转存文件，每次转存后执行
+func (w *FileLogWriter) applyRetention() error {
+	if w.maxbackups <= 0 && w.maxage <= 0 && w.maxtotal <= 0 {
+		return nil
+	}
+	backups, err := w.listBackups()
+	if err != nil {
+		return fmt.Errorf("Retention: %v", err)
+	}
+
+	var total int64
+	if info, err := os.Stat(w.dir + w.filename); err == nil {
+		total = info.Size()
+	}
+	now := time.Now()
+	kept := 0
+	full := false // 超出总大小后更旧的文件全部删除
+	var errs []string
+	for _, backup := range backups {
+		if w.maxtotal > 0 && total+backup.size > w.maxtotal {
+			full = true
+		}
+		switch {
+		case full,
+			w.maxbackups > 0 && kept >= w.maxbackups,
+			w.maxage > 0 && now.Sub(backup.modTime) > w.maxage:
+			if err := os.Remove(w.dir + backup.name); err != nil && os.IsNotExist(err) == false {
+				errs = append(errs, err.Error())
+			}
+		default:
+			kept++
+			total += backup.size
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("Retention: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// strToDuration 解析时长，支持 d 天后缀，纯数字按天计算
+func strToDuration(str string) (time.Duration, error) {
+	if len(str) <= 0 {
+		return 0, nil
+	}
+	if days, err := strconv.Atoi(strings.TrimSuffix(str, "d")); err == nil {
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(str)
+}
@@ -0,0 +1,141 @@
+package log
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+func writeLines(w *FileLogWriter, count int) {
+	for i := 0; i < count; i++ {
+		w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: fmt.Sprintf("line %d", i)})
+	}
+}
+
+func newRetentionWriter(t *testing.T) (*FileLogWriter, string) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "log4go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir += "/"
+	w := NewFileLogWriter(dir, "app.log", true)
+	if w == nil {
+		t.Fatal("NewFileLogWriter failed")
+	}
+	w.SetFormat("%M").SetRotateLines(10)
+	return w, dir
+}
+
+func backupCount(t *testing.T, w *FileLogWriter) int {
+	t.Helper()
+	backups, err := w.listBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(backups)
+}
+
+func TestRetentionMaxBackups(t *testing.T) {
+	w, dir := newRetentionWriter(t)
+	defer os.RemoveAll(dir)
+	w.SetMaxBackups(3)
+	writeLines(w, 100)
+	w.Close()
+
+	if count := backupCount(t, w); count != 3 {
+		t.Fatalf("got %d backups, want 3", count)
+	}
+	if _, err := os.Stat(dir + "app.log"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetentionMaxAge(t *testing.T) {
+	w, dir := newRetentionWriter(t)
+	defer os.RemoveAll(dir)
+	writeLines(w, 30)
+	w.Close()
+	if count := backupCount(t, w); count != 2 {
+		t.Fatalf("got %d backups, want 2", count)
+	}
+
+	// 将已有的转存文件改为两天前
+	old := time.Now().Add(-48 * time.Hour)
+	backups, _ := w.listBackups()
+	for _, backup := range backups {
+		os.Chtimes(dir+backup.name, old, old)
+	}
+	w = NewFileLogWriter(dir, "app.log", true)
+	w.SetFormat("%M").SetRotateLines(10).SetMaxAge(24 * time.Hour)
+	writeLines(w, 10)
+	w.Rotate()
+	w.Close()
+
+	// 启动时转存的文件与本次转存的文件保留
+	if count := backupCount(t, w); count != 2 {
+		t.Fatalf("got %d backups, want 2", count)
+	}
+	backups, _ = w.listBackups()
+	for _, backup := range backups {
+		if backup.modTime.Before(old.Add(time.Hour)) {
+			t.Fatalf("expired backup %s kept", backup.name)
+		}
+	}
+}
+
+func TestRetentionMaxTotalSize(t *testing.T) {
+	w, dir := newRetentionWriter(t)
+	defer os.RemoveAll(dir)
+	// 每个文件10行80字节，转存时当前文件为空，保留两个转存文件
+	w.SetMaxTotalSize(200)
+	writeLines(w, 100)
+	w.Close()
+
+	backups, err := w.listBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := int64(0)
+	for _, backup := range backups {
+		total += backup.size
+	}
+	if len(backups) != 2 || total > 200 {
+		t.Fatalf("got %d backups of %d bytes, want 2 within 200", len(backups), total)
+	}
+
+	// 超出总大小后更旧的文件即使较小也删除
+	os.Remove(dir + "app.log")
+	for _, backup := range backups {
+		os.Remove(dir + backup.name)
+	}
+	sizes := []int{20, 200, 50}
+	for i, size := range sizes {
+		name := fmt.Sprintf("%sapp.log.%03d", dir, i+1)
+		if err := ioutil.WriteFile(name, make([]byte, size), 0660); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-time.Duration(len(sizes)-i) * time.Hour)
+		os.Chtimes(name, mtime, mtime)
+	}
+	w = NewFileLogWriter(dir, "app.log", true)
+	w.SetFormat("%M").SetMaxTotalSize(150)
+	writeLines(w, 1)
+	w.Rotate()
+	w.Close()
+	backups, err = w.listBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(backups))
+	for _, backup := range backups {
+		names = append(names, backup.name)
+	}
+	if len(backups) != 2 || backups[1].name != "app.log.003" {
+		t.Fatalf("got backups %v, want the newest and app.log.003", names)
+	}
+}
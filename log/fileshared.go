@@ -0,0 +1,124 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// 常量定义
+const (
+	sharedBatchSize = 256 // 多进程模式一次加锁最多写入的日志条数
+)
+
+// NewSharedFileLogWriter 创建多进程共享的文件输出节点
+// 多个进程写同一文件时，写入与转存都在建议锁 (flock) 保护下进行，每次写入前检查文件
+// 是否已被其它进程转存，是则重新打开，转存后不会再写入旧文件；释放锁前刷新缓冲
+// 启动时不会转存已存在的文件，转存行数按本进程计算，大小按文件实际大小计算
+// 依赖 flock，windows 下不支持，创建失败返回nil
+func NewSharedFileLogWriter(dir, fname string, rotate bool) *FileLogWriter {
+	return newFileLogWriter(dir, fname, rotate, true)
+}
+
+// IsShared 是否多进程共享模式
+func (w *FileLogWriter) IsShared() bool {
+	return w.shared
+}
+
+// lockShared 获取转存锁，锁文件与日志文件同目录
+func (w *FileLogWriter) lockShared() error {
+	if w.lock == nil {
+		name := w.dir + strings.Replace(w.filenameFormat, "%", "_", -1) + ".lock"
+		fd, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0660)
+		if err != nil {
+			return fmt.Errorf("Lock: %v", err)
+		}
+		w.lock = fd
+	}
+	if err := lockFile(w.lock, true); err != nil {
+		return fmt.Errorf("Lock: %v", err)
+	}
+	return nil
+}
+
+// unlockShared 释放转存锁
+func (w *FileLogWriter) unlockShared() {
+	if w.lock != nil {
+		unlockFile(w.lock)
+	}
+}
+
+// closeShared 关闭锁文件
+func (w *FileLogWriter) closeShared() {
+	if w.lock != nil {
+		w.lock.Close()
+		w.lock = nil
+	}
+}
+
+// ownsFile 当前打开的文件是否仍是路径上的文件，为false时已被其它进程转存
+func (w *FileLogWriter) ownsFile() bool {
+	if w.file == nil {
+		return false
+	}
+	opened, err := w.file.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(w.dir + w.filename)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(opened, current)
+}
+
+// writeShared 在转存锁内写入本条及通道中已有的日志，释放锁前刷新缓冲
+// 通道已关闭时返回false
+func (w *FileLogWriter) writeShared(rec *Record, timer *time.Timer) bool {
+	if err := w.lockShared(); err != nil {
+		fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
+		return true
+	}
+	w.locked = true
+	defer func() {
+		if err := w.flush(false); err != nil {
+			fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
+		}
+		w.locked = false
+		w.unlockShared()
+	}()
+
+	w.syncShared(timer)
+	for count := 1; ; count++ {
+		w.writeRecord(rec, timer)
+		if count >= sharedBatchSize {
+			return true
+		}
+		var ok bool
+		select {
+		case rec, ok = <-w.rec:
+			if !ok {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+}
+
+// syncShared 检查文件是否已被其它进程转存，是则重新打开并顺延转存时刻，同时以实际大小更新计数
+// 需持有转存锁
+func (w *FileLogWriter) syncShared(timer *time.Timer) {
+	if w.ownsFile() == false {
+		if err := w.intReopen(); err != nil {
+			fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
+		}
+		w.rotateAt = nextRotateTime(time.Now(), w.GetRotateInterval())
+		w.resetTimer(timer)
+		return
+	}
+	if info, err := w.file.Stat(); err == nil {
+		w.maxsizeCursize = int(info.Size())
+	}
+}
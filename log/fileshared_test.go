@@ -0,0 +1,100 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+// countLines 统计目录中日志文件(含.gz)的行数，返回行数及最大文件大小
+func countLines(t *testing.T, dir, prefix string) (int, int64) {
+	t.Helper()
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, maxsize := 0, int64(0)
+	for _, info := range files {
+		name := info.Name()
+		if strings.HasPrefix(name, prefix) == false || strings.HasSuffix(name, ".lock") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(name, compressSuffix) {
+			gz, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if data, err = ioutil.ReadAll(gz); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		if int64(len(data)) > maxsize {
+			maxsize = int64(len(data))
+		}
+		lines += bytes.Count(data, []byte("\n"))
+	}
+	return lines, maxsize
+}
+
+func testSharedWriters(t *testing.T, compress bool) {
+	dir, err := ioutil.TempDir("", "log4go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 每个writer使用独立的锁文件句柄，与多进程的加锁行为一致
+	const W, N, maxsize = 4, 5000, 16 * 1024
+	writers := make([]*FileLogWriter, 0, W)
+	for i := 0; i < W; i++ {
+		w := NewSharedFileLogWriter(dir+"/", "app.log", true)
+		if w == nil {
+			t.Fatal("NewSharedFileLogWriter failed")
+		}
+		w.SetFormat("%M").SetRotateSize(maxsize).SetCompress(compress)
+		writers = append(writers, w)
+	}
+
+	var wg sync.WaitGroup
+	for _, w := range writers {
+		wg.Add(1)
+		go func(w *FileLogWriter) {
+			defer wg.Done()
+			for i := 0; i < N; i++ {
+				w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: "shared line"})
+			}
+		}(w)
+	}
+	wg.Wait()
+	for _, w := range writers {
+		w.Close()
+	}
+
+	lines, size := countLines(t, dir, "app.log")
+	if lines != W*N {
+		t.Fatalf("got %d lines, want %d", lines, W*N)
+	}
+	if size > maxsize+int64(len("shared line\n")) {
+		t.Fatalf("file size %d exceeds maxsize %d", size, maxsize)
+	}
+}
+
+func TestSharedFileLogWriter(t *testing.T) {
+	testSharedWriters(t, false)
+}
+
+func TestSharedFileLogWriterCompress(t *testing.T) {
+	testSharedWriters(t, true)
+}
@@ -0,0 +1,124 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 常量定义
+const (
+	RotateMinutely = time.Minute    // 每分钟转存
+	RotateHourly   = time.Hour      // 每小时转存
+	RotateDaily    = 24 * time.Hour // 每天转存
+)
+
+// SetRotateInterval 设置按时间间隔转存，0 关闭
+// 转存时刻按本地时间对齐：小于一天的间隔从每天零点起按间隔切分，如 15m 在 00:15、00:30 转存
+// 一天及以上的间隔按整天计算，在零点转存，不足一天的部分忽略
+// 到点由定时器触发，无日志输出时也会按时转存
+func (w *FileLogWriter) SetRotateInterval(interval time.Duration) *FileLogWriter {
+	if interval < 0 {
+		interval = 0
+	}
+	atomic.StoreInt64(&w.interval, int64(interval))
+	w.notifyReset()
+	return w
+}
+
+// GetRotateInterval 获取转存时间间隔
+func (w *FileLogWriter) GetRotateInterval() time.Duration {
+	return time.Duration(atomic.LoadInt64(&w.interval))
+}
+
+// nextRotateTime 下一个转存时刻，interval <= 0 时返回零值
+func nextRotateTime(now time.Time, interval time.Duration) time.Time {
+	if interval <= 0 {
+		return time.Time{}
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	tomorrow := midnight.AddDate(0, 0, 1)
+	if interval >= RotateDaily {
+		// 按日历天计算，夏令时切换当天仍在零点转存
+		return midnight.AddDate(0, 0, int(interval/RotateDaily))
+	}
+	next := midnight.Add((now.Sub(midnight)/interval + 1) * interval)
+	if next.After(tomorrow) {
+		next = tomorrow
+	}
+	return next
+}
+
+// expandFilename 展开文件名模板：%D 日期 2006-01-02，%H 小时，%M 分钟，%% 为 %
+func expandFilename(format string, now time.Time) string {
+	out := bytes.NewBuffer(make([]byte, 0, len(format)+16))
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 >= len(format) {
+			out.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'D':
+			out.WriteString(now.Format("2006-01-02"))
+		case 'H':
+			out.WriteString(now.Format("15"))
+		case 'M':
+			out.WriteString(now.Format("04"))
+		case '%':
+			out.WriteByte('%')
+		default:
+			out.WriteByte('%')
+			out.WriteByte(format[i])
+		}
+	}
+	return out.String()
+}
+
+// filenamePattern 文件名模板对应的正则，时间部分可变
+func filenamePattern(format string) string {
+	out := bytes.NewBuffer(make([]byte, 0, len(format)*2))
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 >= len(format) {
+			out.WriteString(regexp.QuoteMeta(format[i : i+1]))
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'D':
+			out.WriteString(`\d{4}-\d{2}-\d{2}`)
+		case 'H', 'M':
+			out.WriteString(`\d{2}`)
+		case '%':
+			out.WriteString("%")
+		default:
+			out.WriteString(regexp.QuoteMeta(format[i-1 : i+1]))
+		}
+	}
+	return out.String()
+}
+
+// strToInterval 解析转存间隔，支持 minutely/hourly/daily 或时长如 15m、6h，超过一天时须为整天如 48h
+func strToInterval(str string) (time.Duration, error) {
+	switch strings.ToLower(str) {
+	case "", "none", "false":
+		return 0, nil
+	case "minutely":
+		return RotateMinutely, nil
+	case "hourly":
+		return RotateHourly, nil
+	case "daily", "true":
+		return RotateDaily, nil
+	}
+	interval, err := time.ParseDuration(str)
+	if err == nil && interval < time.Second {
+		err = fmt.Errorf("interval must be at least 1s")
+	}
+	if err == nil && interval > RotateDaily && interval%RotateDaily != 0 {
+		err = fmt.Errorf("interval over 24h must be whole days")
+	}
+	return interval, err
+}
@@ -0,0 +1,64 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRotateTime(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available:", err)
+	}
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, loc)
+	}
+	tests := []struct {
+		now      time.Time
+		interval time.Duration
+		want     time.Time
+	}{
+		{at(3, 7, 10, 0), 0, time.Time{}},
+		{at(3, 7, 10, 0), RotateDaily, at(3, 8, 0, 0)},
+		// 夏令时开始当天只有23小时
+		{at(3, 8, 10, 0), RotateDaily, at(3, 9, 0, 0)},
+		// 夏令时结束当天有25小时
+		{at(11, 1, 10, 0), RotateDaily, at(11, 2, 0, 0)},
+		{at(11, 1, 10, 0), 2 * RotateDaily, at(11, 3, 0, 0)},
+		{at(3, 7, 10, 20), RotateHourly, at(3, 7, 11, 0)},
+		{at(3, 8, 3, 20), RotateHourly, at(3, 8, 4, 0)},
+		{at(3, 7, 10, 20), 15 * time.Minute, at(3, 7, 10, 30)},
+		{at(3, 7, 23, 50), 7 * time.Hour, at(3, 8, 0, 0)},
+		// 超过一天的间隔按整天计算
+		{at(3, 7, 10, 0), 36 * time.Hour, at(3, 8, 0, 0)},
+		{at(3, 8, 0, 0), 36 * time.Hour, at(3, 9, 0, 0)},
+	}
+	for _, test := range tests {
+		if got := nextRotateTime(test.now, test.interval); got.Equal(test.want) == false {
+			t.Errorf("nextRotateTime(%v, %v) = %v, want %v", test.now, test.interval, got, test.want)
+		}
+	}
+}
+
+func TestStrToInterval(t *testing.T) {
+	tests := []struct {
+		str  string
+		want time.Duration
+		bad  bool
+	}{
+		{"", 0, false},
+		{"hourly", RotateHourly, false},
+		{"daily", RotateDaily, false},
+		{"15m", 15 * time.Minute, false},
+		{"48h", 2 * RotateDaily, false},
+		{"36h", 0, true},
+		{"500ms", 0, true},
+		{"often", 0, true},
+	}
+	for _, test := range tests {
+		got, err := strToInterval(test.str)
+		if (err != nil) != test.bad || (test.bad == false && got != test.want) {
+			t.Errorf("strToInterval(%q) = %v, %v", test.str, got, err)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !windows
+// +build !windows
+
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+// flockSupported 是否支持建议锁
+const flockSupported = true
+
+// lockFile 对文件加排他建议锁，wait 为false时锁被占用立即返回错误
+func lockFile(f *os.File, wait bool) error {
+	how := syscall.LOCK_EX
+	if wait == false {
+		how |= syscall.LOCK_NB
+	}
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+// unlockFile 释放建议锁
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,19 @@
+//go:build windows
+// +build windows
+
+package log
+
+import "os"
+
+// flockSupported windows 下不支持建议锁，多进程共享模式不可用
+const flockSupported = false
+
+// lockFile windows 下为空操作，仅用于单进程内的压缩
+func lockFile(f *os.File, wait bool) error {
+	return nil
+}
+
+// unlockFile 释放建议锁
+func unlockFile(f *os.File) error {
+	return nil
+}
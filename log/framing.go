@@ -0,0 +1,105 @@
+package log
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// 流式传输的消息分帧方式
+const (
+	FramingNone    = "none"    // 不分帧，消息首尾相连，适用于udp
+	FramingNewline = "newline" // 每条消息以换行结尾
+	FramingOctet   = "octet"   // RFC 6587 octet-counting，"长度 空格 消息"
+	FramingLength  = "length"  // 4字节大端长度前缀
+)
+
+// 常量定义
+const (
+	MaxFrameSize = 16 * 1024 * 1024 // 读取时允许的最大消息长度
+)
+
+// ParseFraming 解析分帧方式，空字符串为 FramingNone
+func ParseFraming(str string) (string, error) {
+	switch framing := strings.ToLower(str); framing {
+	case "":
+		return FramingNone, nil
+	case FramingNone, FramingNewline, FramingOctet, FramingLength:
+		return framing, nil
+	}
+	return FramingNone, fmt.Errorf("unknown framing, use none, newline, octet or length")
+}
+
+// AppendFrame 按分帧方式将消息追加到 dst
+// newline 分帧时消息中已有的结尾换行不会重复添加
+func AppendFrame(dst []byte, framing string, msg []byte) []byte {
+	switch framing {
+	case FramingNewline:
+		dst = append(dst, msg...)
+		if len(msg) <= 0 || msg[len(msg)-1] != '\n' {
+			dst = append(dst, '\n')
+		}
+	case FramingOctet:
+		dst = strconv.AppendInt(dst, int64(len(msg)), 10)
+		dst = append(dst, ' ')
+		dst = append(dst, msg...)
+	case FramingLength:
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(msg)))
+		dst = append(dst, size[:]...)
+		dst = append(dst, msg...)
+	default:
+		dst = append(dst, msg...)
+	}
+	return dst
+}
+
+// ReadFrame 按分帧方式读取一条消息，newline 分帧返回的消息不含换行
+// FramingNone 无法区分消息边界，返回当前可读取的全部数据
+func ReadFrame(r *bufio.Reader, framing string) ([]byte, error) {
+	switch framing {
+	case FramingNewline:
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				return line, nil
+			}
+			return nil, err
+		}
+		return line[:len(line)-1], nil
+	case FramingOctet:
+		digits, err := r.ReadString(' ')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(digits[:len(digits)-1])
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid octet count %q", digits)
+		}
+		return readFrameBody(r, size)
+	case FramingLength:
+		var head [4]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			return nil, err
+		}
+		return readFrameBody(r, int(binary.BigEndian.Uint32(head[:])))
+	default:
+		buf := make([]byte, 64*1024)
+		n, err := r.Read(buf)
+		return buf[:n], err
+	}
+}
+
+func readFrameBody(r *bufio.Reader, size int) ([]byte, error) {
+	if size > MaxFrameSize {
+		return nil, fmt.Errorf("frame size %d exceeds %d", size, MaxFrameSize)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFramingRoundTrip(t *testing.T) {
+	messages := [][]byte{
+		[]byte("first"),
+		[]byte(""),
+		[]byte("with space and 12 digits"),
+		[]byte(strings.Repeat("long ", 1000)),
+		[]byte("last"),
+	}
+	for _, framing := range []string{FramingNewline, FramingOctet, FramingLength} {
+		var stream []byte
+		for _, msg := range messages {
+			stream = AppendFrame(stream, framing, msg)
+		}
+		reader := bufio.NewReader(bytes.NewReader(stream))
+		for i, msg := range messages {
+			got, err := ReadFrame(reader, framing)
+			if err != nil {
+				t.Fatalf("%s #%d: %v", framing, i, err)
+			}
+			if bytes.Equal(got, msg) == false {
+				t.Fatalf("%s #%d: got %q, want %q", framing, i, got, msg)
+			}
+		}
+		if _, err := ReadFrame(reader, framing); err != io.EOF {
+			t.Fatalf("%s: got %v at end of stream, want EOF", framing, err)
+		}
+	}
+}
+
+func TestAppendFrame(t *testing.T) {
+	tests := []struct {
+		framing string
+		msg     string
+		want    string
+	}{
+		{FramingNone, "msg", "msg"},
+		{FramingNewline, "msg", "msg\n"},
+		{FramingNewline, "msg\n", "msg\n"},
+		{FramingOctet, "msg", "3 msg"},
+		{FramingLength, "msg", "\x00\x00\x00\x03msg"},
+	}
+	for _, test := range tests {
+		if got := string(AppendFrame(nil, test.framing, []byte(test.msg))); got != test.want {
+			t.Errorf("AppendFrame(%s, %q) = %q, want %q", test.framing, test.msg, got, test.want)
+		}
+	}
+}
+
+func TestReadFrameErrors(t *testing.T) {
+	if _, err := ReadFrame(bufio.NewReader(strings.NewReader("x1 msg")), FramingOctet); err == nil {
+		t.Fatal("invalid octet count accepted")
+	}
+	if _, err := ReadFrame(bufio.NewReader(strings.NewReader("\x7f\x00\x00\x00")), FramingLength); err == nil {
+		t.Fatal("oversized frame accepted")
+	}
+	if _, err := ReadFrame(bufio.NewReader(strings.NewReader("5 ab")), FramingOctet); err == nil {
+		t.Fatal("truncated frame accepted")
+	}
+	if _, err := ParseFraming("lines"); err == nil {
+		t.Fatal("unknown framing accepted")
+	}
+}
@@ -2,14 +2,18 @@ package log
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math/rand"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/lerryxiao/log4go/log/define"
@@ -26,6 +30,7 @@ type RequestLogger struct {
 	datetime string
 	url      string
 	header   interface{}
+	fields   define.Fields
 }
 
 // FlumeData 存储数据结构
@@ -49,6 +54,18 @@ func (logger *RequestLogger) transRequest(writer *HTTPLogWriter) (*http.Request,
 				}
 			}
 		}
+		if len(logger.fields) > 0 && writer.rptype == define.FLUME {
+			merged := make(map[string]interface{})
+			if headers != nil {
+				for key, value := range *headers {
+					merged[key] = value
+				}
+			}
+			for _, field := range logger.fields {
+				merged[field.Key] = field.ValueString()
+			}
+			headers = &merged
+		}
 		var (
 			data []byte
 			err  error
@@ -137,11 +154,10 @@ func (proc *HTTPLoggerProc) startLogger() {
 EXIT:
 }
 
-// 停止日志协程
+// 停止日志协程，缓冲中的日志处理完成后返回
 func (proc *HTTPLoggerProc) stopLogger() {
-	proc.stop <- true
-	<-proc.stop
 	close(proc.loggers)
+	<-proc.stop
 }
 
 // 处理日志
@@ -156,13 +172,12 @@ func (proc *HTTPLoggerProc) saveLogger(logger *RequestLogger) {
 		return
 	}
 
-	client := &http.Client{}
-	client.Timeout = time.Duration(10) * time.Second
-	response, err := client.Do(req)
+	response, err := proc.writer.getClient().Do(req)
 	if err != nil || response == nil {
 		fmt.Fprintf(os.Stderr, "save log requst failed, api is %s, err is %v", proc.writer.url, err)
 		return
 	}
+	io.Copy(ioutil.Discard, response.Body)
 	response.Body.Close()
 }
 
@@ -172,6 +187,7 @@ type HTTPLogWriter struct {
 	prand   *rand.Rand             // 随机数
 	url     string                 // 上报链接
 	headers map[string]interface{} // http headers
+	client  atomic.Value           // *http.Client
 	rptype  uint8
 }
 
@@ -193,6 +209,7 @@ func NewHTTPLogWriter(url string, header map[string]interface{}, procSize int) *
 		url:     url,
 		headers: header,
 	}
+	w.SetTLSConfig(nil)
 
 	for i := 0; i < procSize; i++ {
 		w.procs[i] = NewHTTPLoggerProc(w, define.LogBufferLength)
@@ -206,6 +223,24 @@ func NewHTTPLogWriter(url string, header map[string]interface{}, procSize int) *
 	return w
 }
 
+// SetTLSConfig 设置https连接的TLS配置，nil 时使用默认配置
+// 需要校验客户端证书时在 config.Certificates 中设置客户端证书，可通过 TLSOptions 创建
+func (w *HTTPLogWriter) SetTLSConfig(config *tls.Config) *HTTPLogWriter {
+	client := &http.Client{Timeout: time.Duration(10) * time.Second}
+	if config != nil {
+		// Keep the default dial, idle connection and handshake timeouts
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = config
+		client.Transport = transport
+	}
+	w.client.Store(client)
+	return w
+}
+
+func (w *HTTPLogWriter) getClient() *http.Client {
+	return w.client.Load().(*http.Client)
+}
+
 // SetURL 成员方法
 func (w *HTTPLogWriter) SetURL(url string) {
 	w.url = url
@@ -234,6 +269,9 @@ func (w *HTTPLogWriter) LogWrite(rec *Record) {
 		if len(rec.Message) > 0 {
 			body = rec.Message
 		}
+		if w.rptype != define.FLUME {
+			body += formatFields(rec)
+		}
 		if len(rec.Extend) > 0 {
 			switch etp, edata := rec.GetExtend(); etp {
 			case define.EXUrlHeadBody:
@@ -263,6 +301,7 @@ func (w *HTTPLogWriter) LogWrite(rec *Record) {
 					datetime: rec.Created.Format(TimeFormateUnix),
 					url:      url,
 					header:   header,
+					fields:   rec.Fields,
 				}
 			}
 		}
@@ -291,14 +330,31 @@ func (w *HTTPLogWriter) GetReportType() uint8 {
 
 // XMLToHTTPLogWriter xml创建http日志输出
 func XMLToHTTPLogWriter(filename string, props []define.XMLProperty) (Writer, bool) {
+	return define.UnwrapCreater(XMLToHTTPLogWriterE)(filename, props)
+}
+
+// XMLToHTTPLogWriterE xml创建http日志输出，返回详细错误
+func XMLToHTTPLogWriterE(filename string, props []define.XMLProperty) (Writer, error) {
+	return define.ParserCreater(XMLParseHTTPLogWriter)(filename, props)
+}
+
+// XMLParseHTTPLogWriter 解析http日志输出的xml配置，返回打开函数
+func XMLParseHTTPLogWriter(filename string, props []define.XMLProperty) (define.WriterOpener, error) {
 	var (
 		url     string
 		headers = make(map[string]interface{})
 		procnum int
+		cerr    = define.NewConfigError(filename)
+
+		tlsOpts    TLSOptions
+		tlsEnabled bool
 	)
 
 	// Parse properties
 	for _, prop := range props {
+		if parseTLSProperty(&tlsOpts, &tlsEnabled, prop.Name, strings.Trim(prop.Value, " \r\n")) {
+			continue
+		}
 		switch prop.Name {
 		case "url":
 			url = strings.Trim(prop.Value, " \r\n")
@@ -315,17 +371,31 @@ func XMLToHTTPLogWriter(filename string, props []define.XMLProperty) (Writer, bo
 				}
 			}
 		case "procnum":
-			procnum, _ = strconv.Atoi(strings.Trim(prop.Value, " \r\n"))
+			var err error
+			if procnum, err = strconv.Atoi(strings.Trim(prop.Value, " \r\n")); err != nil {
+				cerr.Addf("Invalid value %q for property \"%s\" of http filter: %v", prop.Value, prop.Name, err)
+			}
 		default:
-			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for file filter in %s\n", prop.Name, filename)
+			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for http filter in %s\n", prop.Name, filename)
 		}
 	}
 
 	// Check properties
 	if len(url) == 0 {
-		fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Required property \"%s\" for file filter missing in %s\n", "url", filename)
-		return nil, false
+		cerr.Addf("Required property \"%s\" for http filter missing", "url")
+	}
+	var tlsConfig *tls.Config
+	if tlsEnabled {
+		var err error
+		if tlsConfig, err = tlsOpts.Config(); err != nil {
+			cerr.Addf("Invalid TLS settings of http filter: %v", err)
+		}
+	}
+	if err := cerr.Err(); err != nil {
+		return nil, err
 	}
 
-	return NewHTTPLogWriter(url, headers, procnum), true
+	return func() (Writer, error) {
+		return NewHTTPLogWriter(url, headers, procnum).SetTLSConfig(tlsConfig), nil
+	}, nil
 }
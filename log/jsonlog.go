@@ -0,0 +1,150 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+// 常量定义
+const (
+	FormatJSON = "json" // json行格式
+)
+
+// RecordFormatter 日志记录格式化，返回内容以换行结尾
+type RecordFormatter interface {
+	Format(rec *Record) string
+}
+
+// NewRecordFormatter 根据格式创建格式化器，json 为json行格式，其它为模板格式
+func NewRecordFormatter(format string) RecordFormatter {
+	if format == FormatJSON {
+		return NewJSONFormatter()
+	}
+	return CompileFormat(format)
+}
+
+// formatterBox atomic.Value 要求存储类型一致
+type formatterBox struct {
+	RecordFormatter
+}
+
+// formatterValue 可并发替换的格式化器
+type formatterValue struct {
+	value atomic.Value
+}
+
+func (fv *formatterValue) Store(formatter RecordFormatter) {
+	fv.value.Store(formatterBox{formatter})
+}
+
+func (fv *formatterValue) Load() RecordFormatter {
+	box, _ := fv.value.Load().(formatterBox)
+	return box.RecordFormatter
+}
+
+////////////////////////////////////////////////////////////////////////////////////
+
+// JSONFormatter json行格式，key为空时不输出该项
+// FieldsKey 为空时字段平铺在顶层，与内置key冲突的字段以 "fields." 为前缀
+type JSONFormatter struct {
+	TimeKey    string
+	LevelKey   string
+	SourceKey  string
+	MessageKey string
+	FieldsKey  string
+	TimeFormat string
+}
+
+// NewJSONFormatter 创建json格式化器
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{
+		TimeKey:    "time",
+		LevelKey:   "level",
+		SourceKey:  "source",
+		MessageKey: "message",
+		TimeFormat: time.RFC3339Nano,
+	}
+}
+
+// SetKeys 设置key名称，格式为 "time:ts;level:severity"，可用名称 time/level/source/message/fields
+func (f *JSONFormatter) SetKeys(keys string) *JSONFormatter {
+	for _, item := range strings.Split(keys, ";") {
+		pair := strings.SplitN(item, ":", 2)
+		if len(pair) < 2 {
+			continue
+		}
+		name, key := strings.TrimSpace(pair[0]), strings.TrimSpace(pair[1])
+		switch name {
+		case "time":
+			f.TimeKey = key
+		case "level":
+			f.LevelKey = key
+		case "source":
+			f.SourceKey = key
+		case "message":
+			f.MessageKey = key
+		case "fields":
+			f.FieldsKey = key
+		}
+	}
+	return f
+}
+
+// Format 格式化为一行json
+func (f *JSONFormatter) Format(rec *Record) string {
+	if rec == nil {
+		return "null\n"
+	}
+	out := bytes.NewBuffer(make([]byte, 0, 256))
+	out.WriteByte('{')
+	first := true
+	write := func(key string, value interface{}) {
+		if len(key) <= 0 {
+			return
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			// 无法序列化的值退化为字符串
+			data, _ = json.Marshal(fmt.Sprint(value))
+		}
+		if first == false {
+			out.WriteByte(',')
+		}
+		first = false
+		name, _ := json.Marshal(key)
+		out.Write(name)
+		out.WriteByte(':')
+		out.Write(data)
+	}
+
+	timeFormat := f.TimeFormat
+	if len(timeFormat) <= 0 {
+		timeFormat = time.RFC3339Nano
+	}
+	write(f.TimeKey, rec.Created.Format(timeFormat))
+	write(f.LevelKey, define.GetLevelName(rec.Level))
+	write(f.SourceKey, rec.Source)
+	write(f.MessageKey, rec.Message)
+	if len(rec.Fields) > 0 {
+		if len(f.FieldsKey) > 0 {
+			write(f.FieldsKey, rec.Fields)
+		} else {
+			for _, field := range rec.Fields {
+				key := field.Key
+				switch key {
+				case f.TimeKey, f.LevelKey, f.SourceKey, f.MessageKey:
+					key = "fields." + key
+				}
+				write(key, field.JSONValue())
+			}
+		}
+	}
+	out.WriteString("}\n")
+	return out.String()
+}
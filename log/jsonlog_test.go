@@ -0,0 +1,79 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+// badJSON 无法序列化为json的值
+type badJSON struct{}
+
+func (badJSON) MarshalJSON() ([]byte, error) { return nil, errors.New("cannot marshal") }
+func (badJSON) String() string               { return "bad value" }
+
+func newJSONRecord(kvs ...interface{}) *Record {
+	return &Record{
+		Level:   define.INFO,
+		Created: time.Date(2026, 3, 8, 9, 5, 7, 0, time.UTC),
+		Source:  "payments.Pay:42",
+		Message: "payment accepted",
+		Fields:  define.MakeFields(kvs...),
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	tests := []struct {
+		name string
+		keys string
+		rec  *Record
+		want string
+	}{
+		{"default", "", newJSONRecord("user", "u-1", "amount", 3),
+			`{"time":"2026-03-08T09:05:07Z","level":"info","source":"payments.Pay:42","message":"payment accepted","user":"u-1","amount":3}`},
+		{"renamed keys", "time:ts; level:severity;message:msg;unknown:x;broken", newJSONRecord(),
+			`{"ts":"2026-03-08T09:05:07Z","severity":"info","source":"payments.Pay:42","msg":"payment accepted"}`},
+		{"empty keys omitted", "time:;source:", newJSONRecord(),
+			`{"level":"info","message":"payment accepted"}`},
+		{"nested fields", "fields:fields", newJSONRecord("user", "u-1", "level", "high"),
+			`{"time":"2026-03-08T09:05:07Z","level":"info","source":"payments.Pay:42","message":"payment accepted","fields":{"user":"u-1","level":"high"}}`},
+		{"colliding fields", "", newJSONRecord("message", "dup", "level", 1, "time", "t", "source", "s"),
+			`{"time":"2026-03-08T09:05:07Z","level":"info","source":"payments.Pay:42","message":"payment accepted","fields.message":"dup","fields.level":1,"fields.time":"t","fields.source":"s"}`},
+		{"collision follows renamed keys", "message:msg", newJSONRecord("message", "kept", "msg", "renamed"),
+			`{"time":"2026-03-08T09:05:07Z","level":"info","source":"payments.Pay:42","msg":"payment accepted","message":"kept","fields.msg":"renamed"}`},
+		{"unmarshallable values", "time:;level:;source:;message:", newJSONRecord("bad", badJSON{}, "nan", math.NaN(), "err", errors.New("boom")),
+			`{"bad":"bad value","nan":"NaN","err":"boom"}`},
+		{"unmarshallable nested values", "time:;level:;source:;message:;fields:f", newJSONRecord("bad", badJSON{}, "nan", math.Inf(1)),
+			`{"f":{"bad":"bad value","nan":"+Inf"}}`},
+	}
+	for _, test := range tests {
+		f := NewJSONFormatter().SetKeys(test.keys)
+		got := f.Format(test.rec)
+		if got != test.want+"\n" {
+			t.Errorf("%s:\n got %s\nwant %s", test.name, got, test.want)
+			continue
+		}
+		if json.Valid([]byte(got)) == false {
+			t.Errorf("%s: invalid json %s", test.name, got)
+		}
+	}
+	if got := NewJSONFormatter().Format(nil); got != "null\n" {
+		t.Errorf("Format(nil) = %q", got)
+	}
+}
+
+func TestJSONFormatterTimeFormat(t *testing.T) {
+	f := NewJSONFormatter().SetKeys("level:;source:;message:")
+	f.TimeFormat = "2006-01-02 15:04:05"
+	if got, want := f.Format(newJSONRecord()), `{"time":"2026-03-08 09:05:07"}`+"\n"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	f.TimeFormat = ""
+	if got, want := f.Format(newJSONRecord()), `{"time":"2026-03-08T09:05:07Z"}`+"\n"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -5,41 +5,370 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	"github.com/lerryxiao/log4go/log/define"
 )
 
 // 常量定义
 const (
-	FormatDefault = "[%D %T] [%L] (%S) %M"
-	FormatShort   = "[%t %d] [%L] %M"
-	FormatAbbrev  = "[%L] %M"
+	FormatDefault = "[%D %T] [%L] (%S) %M%F"
+	FormatShort   = "[%t %d] [%L] %M%F"
+	FormatAbbrev  = "[%L] %M%F"
 )
 
+// 缓冲区定义
+const (
+	formatBufferSize    = 256       // 格式化缓冲区初始大小
+	formatBufferMaxSize = 64 * 1024 // 超过该大小的缓冲区不放回缓冲池
+)
+
+// formatCacheType 按秒缓存的时间字符串
 type formatCacheType struct {
-	LastUpdateSeconds     int64
-	shortTime, shortDate  string
-	longTime, longDate    string
-	runnableID, processID string
+	LastUpdateSeconds    int64
+	shortTime, shortDate string
+	longTime, longDate   string
+	processID, zone      string
+}
+
+var (
+	formatCache  atomic.Value // *formatCacheType
+	formatters   sync.Map     // string -> *Formatter, FormatLogRecord 使用
+	formatBuffer = sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 0, formatBufferSize))
+		},
+	}
+	processID = fmt.Sprintf("%d", os.Getpid())
+)
+
+// getFormatCache 获取记录时间所在秒的缓存，可并发调用
+func getFormatCache(created time.Time) *formatCacheType {
+	secs := created.Unix()
+	if cache, ok := formatCache.Load().(*formatCacheType); ok && cache.LastUpdateSeconds == secs {
+		return cache
+	}
+	month, day, year := created.Month(), created.Day(), created.Year()
+	hour, minute, second := created.Hour(), created.Minute(), created.Second()
+	cache := &formatCacheType{
+		LastUpdateSeconds: secs,
+		shortTime:         fmt.Sprintf("%02d:%02d", hour, minute),
+		shortDate:         fmt.Sprintf("%02d/%02d/%02d", month, day, year%100),
+		longTime:          fmt.Sprintf("%02d:%02d:%02d", hour, minute, second),
+		longDate:          fmt.Sprintf("%04d-%02d-%02d", year, month, day),
+		processID:         processID,
+		zone:              created.Format("-0700"),
+	}
+	formatCache.Store(cache)
+	return cache
 }
 
+// FormatVerb 自定义格式符，返回输出内容
+type FormatVerb = func(rec *Record) string
+
+// 格式符定义
+const (
+	builtinVerbs  = "TtDdLSMFPRNZUsH" // 内置格式符
+	reservedVerbs = "%{}-.0123456789" // 用于语法的字符
+)
+
 var (
-	formatCache = &formatCacheType{}
+	verbMutex   sync.RWMutex
+	formatVerbs = map[byte]FormatVerb{} // 自定义格式符
+	hostname, _ = os.Hostname()
 )
 
+// RegisterFormatVerb 注册自定义格式符，只对之后编译的格式模板生效
+func RegisterFormatVerb(verb byte, fun FormatVerb) error {
+	if fun == nil {
+		return fmt.Errorf("format verb %%%c function is nil", verb)
+	}
+	if verb < '!' || verb > '~' || strings.IndexByte(builtinVerbs, verb) >= 0 || strings.IndexByte(reservedVerbs, verb) >= 0 {
+		return fmt.Errorf("format verb %%%c is builtin or reserved", verb)
+	}
+	verbMutex.Lock()
+	formatVerbs[verb] = fun
+	verbMutex.Unlock()
+
+	// FormatLogRecord 缓存的模板需要重新编译
+	formatters.Range(func(key, value interface{}) bool {
+		formatters.Delete(key)
+		return true
+	})
+	return nil
+}
+
+func getFormatVerb(verb byte) FormatVerb {
+	verbMutex.RLock()
+	defer verbMutex.RUnlock()
+	return formatVerbs[verb]
+}
+
+// formatPart 模板片段，verb 为0时原样输出 text
+type formatPart struct {
+	verb   byte
+	text   []byte
+	key    string     // %{key} 字段名
+	custom FormatVerb // 自定义格式符
+	left   bool       // 左对齐
+	width  int        // 最小宽度，不足时补空格
+	max    int        // 最大宽度，超出时从头部截断
+}
+
+// Formatter 预编译的格式模板，可并发使用
+type Formatter struct {
+	format string
+	parts  []formatPart
+}
+
+// CompileFormat 编译格式模板，格式说明见 FormatLogRecord
+func CompileFormat(format string) *Formatter {
+	f := &Formatter{
+		format: format,
+	}
+	for i := 0; i < len(format); {
+		index := strings.IndexByte(format[i:], '%')
+		if index < 0 {
+			f.appendText(format[i:])
+			break
+		}
+		f.appendText(format[i : i+index])
+		i += index + 1
+		if i >= len(format) {
+			break
+		}
+		if format[i] == '%' {
+			f.appendText("%")
+			i++
+			continue
+		}
+
+		// Modifiers: [-][width][.max]
+		part := formatPart{}
+		if format[i] == '-' {
+			part.left = true
+			i++
+		}
+		part.width, i = parseFormatNum(format, i)
+		if i < len(format) && format[i] == '.' {
+			part.max, i = parseFormatNum(format, i+1)
+		}
+		if i >= len(format) {
+			break
+		}
+
+		part.verb = format[i]
+		i++
+		switch {
+		case part.verb == '{':
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				continue
+			}
+			part.key = format[i : i+end]
+			i += end + 1
+		case strings.IndexByte(builtinVerbs, part.verb) >= 0:
+			if part.verb == 'R' {
+				define.EnableGoroutineID()
+			}
+		default:
+			// Ignores unknown formats
+			if part.custom = getFormatVerb(part.verb); part.custom == nil {
+				continue
+			}
+		}
+		f.parts = append(f.parts, part)
+	}
+	return f
+}
+
+// parseFormatNum 解析格式符中的数字
+func parseFormatNum(format string, i int) (int, int) {
+	num := 0
+	for ; i < len(format) && format[i] >= '0' && format[i] <= '9'; i++ {
+		num = num*10 + int(format[i]-'0')
+	}
+	return num, i
+}
+
+// appendText 合并相邻文本片段
+func (f *Formatter) appendText(text string) {
+	if len(text) <= 0 {
+		return
+	}
+	if last := len(f.parts) - 1; last >= 0 && f.parts[last].verb == 0 {
+		f.parts[last].text = append(f.parts[last].text, text...)
+		return
+	}
+	f.parts = append(f.parts, formatPart{text: []byte(text)})
+}
+
+// String 格式模板
+func (f *Formatter) String() string {
+	return f.format
+}
+
+// Format 格式化日志记录，以换行结尾，模板为空时返回空串
+func (f *Formatter) Format(rec *Record) string {
+	if rec == nil {
+		return "<nil>"
+	}
+	if len(f.format) == 0 {
+		return ""
+	}
+	out := formatBuffer.Get().(*bytes.Buffer)
+	f.write(out, rec)
+	rel := out.String()
+	putFormatBuffer(out)
+	return rel
+}
+
+// Fprint 格式化日志记录并写入 writer，模板为空时不写入
+func (f *Formatter) Fprint(writer io.Writer, rec *Record) (int, error) {
+	if rec == nil || len(f.format) == 0 {
+		return 0, nil
+	}
+	out := formatBuffer.Get().(*bytes.Buffer)
+	f.write(out, rec)
+	n, err := writer.Write(out.Bytes())
+	putFormatBuffer(out)
+	return n, err
+}
+
+func (f *Formatter) write(out *bytes.Buffer, rec *Record) {
+	cache := getFormatCache(rec.Created)
+	for _, part := range f.parts {
+		start := out.Len()
+		switch part.verb {
+		case 0:
+			out.Write(part.text)
+			continue
+		case 'T':
+			out.WriteString(cache.longTime)
+			out.WriteByte(':')
+			writeDigits(out, rec.Created.Nanosecond()/1e6, 3)
+		case 't':
+			out.WriteString(cache.shortTime)
+		case 'D':
+			out.WriteString(cache.longDate)
+		case 'd':
+			out.WriteString(cache.shortDate)
+		case 'N':
+			out.WriteString(cache.longTime)
+			out.WriteByte('.')
+			writeDigits(out, rec.Created.Nanosecond(), 9)
+		case 'Z':
+			out.WriteString(cache.zone)
+		case 'U':
+			out.WriteString(rec.Created.UTC().Format("2006-01-02T15:04:05.000Z"))
+		case 'L':
+			out.WriteString(define.GetLevelName(rec.Level))
+		case 'S':
+			out.WriteString(rec.Source)
+		case 's':
+			out.WriteString(rec.File)
+		case 'M':
+			out.WriteString(rec.Message)
+		case 'F':
+			out.WriteString(formatFields(rec))
+		case '{':
+			if val, ok := rec.Fields.Get(part.key); ok {
+				out.WriteString(define.Field{Key: part.key, Value: val}.ValueString())
+			}
+		case 'P':
+			out.WriteString(cache.processID)
+		case 'R':
+			out.WriteString(strconv.FormatUint(rec.Goroutine, 10))
+		case 'H':
+			out.WriteString(hostname)
+		default:
+			if part.custom != nil {
+				out.WriteString(part.custom(rec))
+			}
+		}
+		if part.width > 0 || part.max > 0 {
+			adjustWidth(out, start, part)
+		}
+	}
+	out.WriteByte('\n')
+}
+
+// writeDigits 输出固定位数的数字，不足补0
+func writeDigits(out *bytes.Buffer, num, width int) {
+	var buf [20]byte
+	for i := width - 1; i >= 0; i-- {
+		buf[i] = byte('0' + num%10)
+		num /= 10
+	}
+	out.Write(buf[:width])
+}
+
+// adjustWidth 按最大宽度截断(保留尾部)，按最小宽度补空格
+func adjustWidth(out *bytes.Buffer, start int, part formatPart) {
+	value := out.Bytes()[start:]
+	count := utf8.RuneCount(value)
+	if part.max > 0 && count > part.max {
+		drop := 0
+		for skip := count - part.max; skip > 0; skip-- {
+			_, size := utf8.DecodeRune(value[drop:])
+			drop += size
+		}
+		kept := append([]byte(nil), value[drop:]...)
+		out.Truncate(start)
+		out.Write(kept)
+		count = part.max
+	}
+	if count >= part.width {
+		return
+	}
+	padding := bytes.Repeat([]byte{' '}, part.width-count)
+	if part.left {
+		out.Write(padding)
+		return
+	}
+	kept := append([]byte(nil), out.Bytes()[start:]...)
+	out.Truncate(start)
+	out.Write(padding)
+	out.Write(kept)
+}
+
+func putFormatBuffer(out *bytes.Buffer) {
+	if out.Cap() <= formatBufferMaxSize {
+		out.Reset()
+		formatBuffer.Put(out)
+	}
+}
+
 // FormatLogRecord Known format codes:
-// %T - Time (15:04:05 MST)
+// %T - Time (15:04:05:000)
 // %t - Time (15:04)
+// %N - Time with nanoseconds (15:04:05.000000000)
 // %D - Date (2006-01-02)
 // %d - Date (01/02/06)
-// %L - Level (FNST, FINE, DEBG, TRAC, WARN, EROR, CRIT)
+// %U - UTC date and time (2006-01-02T15:04:05.000Z)
+// %Z - Zone offset (-0700)
+// %L - Level (fnst, fine, debug, trace, info, warning, error, fatal, report)
 // %S - Source
+// %s - Short file:line of the caller
 // %M - Message
-// Ignores unknown formats
-// Recommended: "[%D %T] [%L] (%S) %M"
+// %F - Fields (key=value pairs, prefixed by a space when present)
+// %{key} - Value of the field key, empty when absent
+// %H - Hostname
+// %% - A literal percent sign
+// Ignores unknown formats, custom verbs can be added by RegisterFormatVerb
+// Recommended: "[%D %T] [%L] (%S) %M%F"
 // add by format by lerry 2015-06-30
 // %P - PROCESS ID
-// %R - thread ID
+// %R - goroutine ID (captured from the first format using it on)
+// Modifiers (log4j style) go between % and the verb:
+// %-8L pads to 8 characters aligned left, %8L aligned right,
+// %.30S keeps the last 30 characters, %-10.10L combines both
+// The compiled template is cached per format string, use CompileFormat to hold it directly
 func FormatLogRecord(format string, rec *Record) string {
 	if rec == nil {
 		return "<nil>"
@@ -47,72 +376,26 @@ func FormatLogRecord(format string, rec *Record) string {
 	if len(format) == 0 {
 		return ""
 	}
-
-	out := bytes.NewBuffer(make([]byte, 0, 64))
-	secs := rec.Created.UnixNano() / 1e9
-
-	cache := *formatCache
-	if cache.LastUpdateSeconds != secs {
-		month, day, year := rec.Created.Month(), rec.Created.Day(), rec.Created.Year()
-		hour, minute, second := rec.Created.Hour(), rec.Created.Minute(), rec.Created.Second()
-		//zone, _ := rec.Created.Zone()
-		millis := rec.Created.UnixNano()/1000000 - (secs * 1000)
-		updated := &formatCacheType{
-			LastUpdateSeconds: secs,
-			shortTime:         fmt.Sprintf("%02d:%02d", hour, minute),
-			shortDate:         fmt.Sprintf("%02d/%02d/%02d", month, day, year%100),
-			longTime:          fmt.Sprintf("%02d:%02d:%02d:%03d", hour, minute, second, millis),
-			longDate:          fmt.Sprintf("%04d-%02d-%02d", year, month, day),
-			runnableID:        fmt.Sprintf("%d", 0),
-			processID:         fmt.Sprintf("%d", os.Getpid()),
-		}
-		cache = *updated
-		formatCache = updated
-	}
-
-	// Split the string into pieces by % signs
-	pieces := bytes.Split([]byte(format), []byte{'%'})
-
-	// Iterate over the pieces, replacing known formats
-	for i, piece := range pieces {
-		if i > 0 && len(piece) > 0 {
-			switch piece[0] {
-			case 'T':
-				out.WriteString(cache.longTime)
-			case 't':
-				out.WriteString(cache.shortTime)
-			case 'D':
-				out.WriteString(cache.longDate)
-			case 'd':
-				out.WriteString(cache.shortDate)
-			case 'L':
-				out.WriteString(define.LevelStrings[rec.Level])
-			case 'S':
-				out.WriteString(rec.Source)
-			case 'M':
-				out.WriteString(rec.Message)
-			//add by lerry suport processID and threadID ,but threadID is 0
-			case 'P':
-				out.WriteString(cache.processID)
-			case 'R':
-				out.WriteString(cache.runnableID)
-			}
-			if len(piece) > 1 {
-				out.Write(piece[1:])
-			}
-		} else if len(piece) > 0 {
-			out.Write(piece)
-		}
+	if f, ok := formatters.Load(format); ok {
+		return f.(*Formatter).Format(rec)
 	}
-	out.WriteByte('\n')
+	f, _ := formatters.LoadOrStore(format, CompileFormat(format))
+	return f.(*Formatter).Format(rec)
+}
 
-	return out.String()
+// formatFields 结构化字段输出，有字段时以空格开头
+func formatFields(rec *Record) string {
+	if len(rec.Fields) <= 0 {
+		return ""
+	}
+	return " " + rec.Fields.String()
 }
 
 // FormatLogWriter This is the standard writer that prints to standard output.
 type FormatLogWriter struct {
-	rec  chan *Record
-	stop chan bool
+	rec    chan *Record
+	stop   chan bool
+	rptype uint8
 }
 
 // NewFormatLogWriter This creates a new FormatLogWriter
@@ -121,6 +404,7 @@ func NewFormatLogWriter(out io.Writer, format string) *FormatLogWriter {
 		rec:  make(chan *Record, define.LogBufferLength),
 		stop: make(chan bool),
 	}
+	formatter := CompileFormat(format)
 
 	go func() {
 		defer func() {
@@ -135,9 +419,10 @@ func NewFormatLogWriter(out io.Writer, format string) *FormatLogWriter {
 				}
 			case rec, ok := <-w.rec:
 				{
-					if ok == true {
-						fmt.Fprint(out, FormatLogRecord(format, rec))
+					if ok == false {
+						goto EXIT
 					}
+					formatter.Fprint(out, rec)
 				}
 			}
 		}
@@ -152,10 +437,20 @@ func (w *FormatLogWriter) LogWrite(rec *Record) {
 	w.rec <- rec
 }
 
-// Close stops the logger from sending messages to standard output.  Attempts to
-// send log messages to this logger after a Close have undefined behavior.
+// Close stops the logger from sending messages to standard output after the buffered
+// messages are written.  Attempts to send log messages to this logger after a Close
+// have undefined behavior.
 func (w *FormatLogWriter) Close() {
-	w.stop <- true
-	<-w.stop
 	close(w.rec)
+	<-w.stop
+}
+
+// SetReportType 设置上报类型
+func (w *FormatLogWriter) SetReportType(tp uint8) {
+	w.rptype = tp
+}
+
+// GetReportType 获取上报类型
+func (w *FormatLogWriter) GetReportType() uint8 {
+	return w.rptype
 }
@@ -0,0 +1,95 @@
+package log
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+func newTestRecord() *Record {
+	return &Record{
+		Level:   define.INFO,
+		Created: time.Date(2026, 3, 8, 9, 5, 7, 123456789, time.UTC),
+		Source:  "github.com/lerryxiao/log4go/examples/payments.Pay:42",
+		File:    "payments.go:42",
+		Message: "payment accepted",
+		Fields:  define.MakeFields("user", "u-1", "amount", 3),
+	}
+}
+
+func TestFormatLogRecord(t *testing.T) {
+	rec := newTestRecord()
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"", ""},
+		{"%M", "payment accepted\n"},
+		{"[%D %T] [%L] %M", "[2026-03-08 09:05:07:123] [info] payment accepted\n"},
+		{"%M%F", "payment accepted user=u-1 amount=3\n"},
+		{"[%-8L]", "[info    ]\n"},
+		{"[%8L]", "[    info]\n"},
+		{"[%.3L]", "[nfo]\n"},
+		{"[%-6.3L]", "[nfo   ]\n"},
+		{"%.30S", "og4go/examples/payments.Pay:42\n"},
+		{"%{user} %{amount} [%{missing}]", "u-1 3 []\n"},
+		{"[%-5{user}]", "[u-1  ]\n"},
+		{"100%% %M", "100% payment accepted\n"},
+		{"%%M", "%M\n"},
+		{"%Q%M", "payment accepted\n"},
+		{"%{user", "user\n"},
+		{"%M %", "payment accepted \n"},
+	}
+	for _, test := range tests {
+		if got := FormatLogRecord(test.format, rec); got != test.want {
+			t.Errorf("FormatLogRecord(%q) = %q, want %q", test.format, got, test.want)
+		}
+	}
+}
+
+func TestCompileFormat(t *testing.T) {
+	rec := newTestRecord()
+	f := CompileFormat("[%-8L] %.10S %M%F")
+	if f.String() != "[%-8L] %.10S %M%F" {
+		t.Fatalf("String() = %q", f.String())
+	}
+	if got := f.Format(rec); got != FormatLogRecord(f.String(), rec) {
+		t.Fatalf("Format = %q, FormatLogRecord = %q", got, FormatLogRecord(f.String(), rec))
+	}
+	if got := f.Format(nil); got != "<nil>" {
+		t.Fatalf("Format(nil) = %q", got)
+	}
+	if err := RegisterFormatVerb('M', nil); err == nil {
+		t.Fatal("builtin verb replaced")
+	}
+}
+
+func BenchmarkFormatLogRecord(b *testing.B) {
+	rec := newTestRecord()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		FormatLogRecord(FormatDefault, rec)
+	}
+}
+
+func BenchmarkFormatter(b *testing.B) {
+	rec := newTestRecord()
+	f := CompileFormat(FormatDefault)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Fprint(ioutil.Discard, rec)
+	}
+}
+
+func BenchmarkFormatterModifiers(b *testing.B) {
+	rec := newTestRecord()
+	f := CompileFormat("[%D %T] [%-8L] (%.30S) %{user} %M")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Fprint(ioutil.Discard, rec)
+	}
+}
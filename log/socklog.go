@@ -1,32 +1,85 @@
 package log
 
 import (
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net"
 	"os"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/lerryxiao/log4go/log/define"
 )
 
+// 常量定义
+const (
+	DefaultSocketQueueSize    = 1024                   // 断开期间默认缓存的日志条数
+	DefaultSocketReconnectMin = 100 * time.Millisecond // 默认最小重连间隔
+	DefaultSocketReconnectMax = 30 * time.Second       // 默认最大重连间隔
+	DefaultSocketWriteTimeout = 5 * time.Second        // 默认写超时
+	DefaultUDPDatagramSize    = 65507                  // udp 默认最大数据报
+	DefaultUnixDatagramSize   = 64 * 1024              // unixgram 默认最大数据报
+)
+
 // SocketLogWriter This log writer sends output to a socket
+// 连接在后台建立，断开后按指数退避加随机抖动重连，断开期间日志缓存在有界队列中，队列满时丢弃最旧的日志
+// 连接正常时输出等待发送，对端停止读取时最多等待写超时后视为断开
 type SocketLogWriter struct {
 	rec    chan *Record
 	stop   chan bool
 	rptype uint8
+
+	proto    string
+	hostport string
+	sock     net.Conn
+	tls      *tls.Config
+	dialed   chan dialResult
+	dialing  bool
+
+	// Message payload and framing, the frame buffer is reused
+	formatter   formatterValue
+	framing     atomic.Value
+	frame       []byte
+	maxDatagram int64
+
+	// Records kept while disconnected, owned by the writer goroutine
+	queue     []*Record
+	queueSize int64
+
+	// Reconnect with exponential backoff
+	reconnectMin int64
+	reconnectMax int64
+	backoff      time.Duration
+	writeTimeout int64
+	prand        *rand.Rand
+
+	dropped   uint64
+	reported  uint64
+	truncated uint64
 }
 
-// LogWrite This is the SocketLogWriter's output method
+// dialResult 后台连接结果
+type dialResult struct {
+	sock net.Conn
+	err  error
+}
+
+// LogWrite This is the SocketLogWriter's output method.  This will block if the output buffer is full.
+// 断开期间日志进入有界队列，不会一直阻塞
 func (w *SocketLogWriter) LogWrite(rec *Record) {
 	w.rec <- rec
 }
 
-// Close 关闭
+// Close 关闭，缓冲中的日志输出完成后返回，连接不可用时丢弃剩余日志
 func (w *SocketLogWriter) Close() {
-	w.stop <- true
-	<-w.stop
 	close(w.rec)
+	<-w.stop
 }
 
 // SetReportType 设置上报类型
@@ -39,50 +92,152 @@ func (w *SocketLogWriter) GetReportType() uint8 {
 	return w.rptype
 }
 
-// NewSocketLogWriter 新建socket log writer
-func NewSocketLogWriter(proto, hostport string) *SocketLogWriter {
-	sock, err := net.Dial(proto, hostport)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "NewSocketLogWriter(%q): %s\n", hostport, err)
-		return nil
+// SetFormatter 设置消息格式化器，未设置时发送json格式的日志记录，格式化结果结尾的换行不发送
+func (w *SocketLogWriter) SetFormatter(formatter RecordFormatter) *SocketLogWriter {
+	if formatter != nil {
+		w.formatter.Store(formatter)
+	}
+	return w
+}
+
+// SetFraming 设置消息分帧方式 FramingNone/FramingNewline/FramingOctet/FramingLength
+// tcp 接收端需要分帧才能区分每条日志
+func (w *SocketLogWriter) SetFraming(framing string) *SocketLogWriter {
+	w.framing.Store(framing)
+	return w
+}
+
+// GetFraming 获取消息分帧方式
+func (w *SocketLogWriter) GetFraming() string {
+	framing, _ := w.framing.Load().(string)
+	if len(framing) <= 0 {
+		return FramingNone
+	}
+	return framing
+}
+
+// SetMaxDatagramSize 设置数据报 (udp/unixgram) 最大长度，超出的日志截断，<= 0 时使用默认值
+func (w *SocketLogWriter) SetMaxDatagramSize(size int) *SocketLogWriter {
+	atomic.StoreInt64(&w.maxDatagram, int64(size))
+	return w
+}
+
+// SetQueueSize 设置断开期间缓存的日志条数，<= 0 时使用默认值
+func (w *SocketLogWriter) SetQueueSize(size int) *SocketLogWriter {
+	atomic.StoreInt64(&w.queueSize, int64(size))
+	return w
+}
+
+// SetReconnect 设置重连间隔范围，每次失败间隔加倍直到最大值，<= 0 时使用默认值
+func (w *SocketLogWriter) SetReconnect(min, max time.Duration) *SocketLogWriter {
+	atomic.StoreInt64(&w.reconnectMin, int64(min))
+	atomic.StoreInt64(&w.reconnectMax, int64(max))
+	return w
+}
+
+// SetWriteTimeout 设置写超时，超时视为连接断开，<= 0 时使用默认值
+func (w *SocketLogWriter) SetWriteTimeout(timeout time.Duration) *SocketLogWriter {
+	atomic.StoreInt64(&w.writeTimeout, int64(timeout))
+	return w
+}
+
+func (w *SocketLogWriter) getWriteTimeout() time.Duration {
+	timeout := time.Duration(atomic.LoadInt64(&w.writeTimeout))
+	if timeout <= 0 {
+		timeout = DefaultSocketWriteTimeout
 	}
+	return timeout
+}
+
+// Truncated 累计因超过数据报长度被截断的日志条数
+func (w *SocketLogWriter) Truncated() uint64 {
+	return atomic.LoadUint64(&w.truncated)
+}
 
+// Dropped 累计丢弃的日志条数
+func (w *SocketLogWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// NewSocketLogWriter 新建socket log writer，对端不可用时以断开状态启动并自动重连
+func NewSocketLogWriter(proto, hostport string) *SocketLogWriter {
+	return NewTLSSocketLogWriter(proto, hostport, nil)
+}
+
+// NewTLSSocketLogWriter 新建TLS socket log writer，config 为nil时不加密，仅支持tcp
+// proto 支持 tcp/udp 及 unix/unixgram，unix 时 hostport 为socket路径
+// 需要校验客户端证书时在 config.Certificates 中设置客户端证书，可通过 TLSOptions 创建
+func NewTLSSocketLogWriter(proto, hostport string, config *tls.Config) *SocketLogWriter {
 	w := &SocketLogWriter{
-		rec:  make(chan *Record, define.LogBufferLength),
-		stop: make(chan bool),
+		rec:      make(chan *Record, define.LogBufferLength),
+		stop:     make(chan bool),
+		proto:    proto,
+		hostport: hostport,
+		tls:      config,
+		dialed:   make(chan dialResult, 1),
+		prand:    rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 
 	go func() {
+		timer := time.NewTimer(time.Hour)
+		timer.Stop()
 		defer func() {
-			if sock != nil && proto == "tcp" {
-				sock.Close()
+			timer.Stop()
+			if w.sock != nil {
+				w.sock.Close()
 			}
 			w.stop <- true
 		}()
+		w.startDial()
 		for {
 			select {
 			case <-w.stop:
 				{
 					goto EXIT
 				}
+			case <-timer.C:
+				{
+					w.startDial()
+				}
+			case res := <-w.dialed:
+				{
+					if w.connected(res) == false {
+						timer.Reset(w.nextBackoff())
+					}
+				}
 			case rec, ok := <-w.rec:
 				{
 					if ok == false {
+						// Last chance to deliver the queued records
+						if w.sock == nil {
+							if w.dialing == false {
+								w.startDial()
+							}
+							w.connected(<-w.dialed)
+						}
+						if w.sock == nil && len(w.queue) > 0 {
+							fmt.Fprintf(os.Stderr, "SocketLogWriter(%v): closed while disconnected, %d records dropped\n", w.hostport, len(w.queue))
+							w.dropRecords(len(w.queue))
+						}
 						goto EXIT
 					}
 					if rec == nil {
 						continue
 					}
-					// Marshall into JSON
-					js, err := json.Marshal(rec)
-					if err != nil {
-						fmt.Fprintf(os.Stderr, "SocketLogWriter(%v): %v", hostport, err)
-						return
+					if w.sock == nil && w.dialing && len(w.queue) >= w.queueLimit() {
+						// Wait for the pending dial before dropping records
+						if w.connected(<-w.dialed) == false {
+							timer.Reset(w.nextBackoff())
+						}
 					}
-					_, err = sock.Write(js)
-					if err != nil {
-						fmt.Fprintf(os.Stderr, "SocketLogWriter(%v): %v", hostport, err)
-						return
+					if w.sock == nil {
+						w.enqueue(rec)
+						continue
+					}
+					if err := w.send(rec); err != nil {
+						w.disconnect(err)
+						w.enqueue(rec)
+						timer.Reset(w.nextBackoff())
 					}
 				}
 			}
@@ -93,28 +248,260 @@ func NewSocketLogWriter(proto, hostport string) *SocketLogWriter {
 	return w
 }
 
+// startDial 在后台建立连接，结果发送到 dialed，连接超时与写超时相同
+func (w *SocketLogWriter) startDial() {
+	if w.dialing || w.sock != nil {
+		return
+	}
+	w.dialing = true
+	timeout := w.getWriteTimeout()
+	go func() {
+		if strings.HasPrefix(w.proto, "unix") {
+			if err := checkSocketPath(w.hostport); err != nil {
+				w.dialed <- dialResult{err: err}
+				return
+			}
+		}
+		dialer := &net.Dialer{Timeout: timeout}
+		var res dialResult
+		if w.tls != nil {
+			res.sock, res.err = tls.DialWithDialer(dialer, w.proto, w.hostport, w.tls)
+		} else {
+			res.sock, res.err = dialer.Dial(w.proto, w.hostport)
+		}
+		w.dialed <- res
+	}()
+}
+
+// disconnect 关闭出错的连接
+func (w *SocketLogWriter) disconnect(err error) {
+	fmt.Fprintf(os.Stderr, "SocketLogWriter(%v): %v, reconnecting\n", w.hostport, err)
+	if w.sock != nil {
+		w.sock.Close()
+		w.sock = nil
+	}
+}
+
+// connected 处理连接结果，成功时发送断开期间缓存的日志，全部发送成功时返回true
+// 启动或断开后首次连接失败时输出错误
+func (w *SocketLogWriter) connected(res dialResult) bool {
+	w.dialing = false
+	if res.err != nil {
+		if w.backoff == 0 {
+			fmt.Fprintf(os.Stderr, "SocketLogWriter(%v): %v, reconnecting\n", w.hostport, res.err)
+		}
+		return false
+	}
+	w.sock = res.sock
+	w.backoff = 0
+	for len(w.queue) > 0 {
+		if err := w.send(w.queue[0]); err != nil {
+			w.disconnect(err)
+			return false
+		}
+		w.queue[0] = nil
+		w.queue = w.queue[1:]
+	}
+	w.queue = nil
+	if dropped := w.Dropped(); dropped != w.reported {
+		fmt.Fprintf(os.Stderr, "SocketLogWriter(%v): connected, %d records dropped while disconnected\n", w.hostport, dropped-w.reported)
+		w.reported = dropped
+	}
+	return true
+}
+
+// nextBackoff 下一次重连等待时间，指数增长，取 [d/2, d] 之间的随机值
+func (w *SocketLogWriter) nextBackoff() time.Duration {
+	min := time.Duration(atomic.LoadInt64(&w.reconnectMin))
+	if min <= 0 {
+		min = DefaultSocketReconnectMin
+	}
+	max := time.Duration(atomic.LoadInt64(&w.reconnectMax))
+	if max <= 0 {
+		max = DefaultSocketReconnectMax
+	}
+	if max < min {
+		max = min
+	}
+	switch {
+	case w.backoff < min:
+		w.backoff = min
+	case w.backoff < max:
+		w.backoff *= 2
+	}
+	if w.backoff > max {
+		w.backoff = max
+	}
+	half := int64(w.backoff / 2)
+	return time.Duration(half + w.prand.Int63n(half+1))
+}
+
+// enqueue 断开期间缓存日志，队列满时丢弃最旧的日志
+func (w *SocketLogWriter) enqueue(rec *Record) {
+	if over := len(w.queue) + 1 - w.queueLimit(); over > 0 {
+		w.dropRecords(over)
+	}
+	w.queue = append(w.queue, rec)
+}
+
+// queueLimit 断开期间缓存的日志条数
+func (w *SocketLogWriter) queueLimit() int {
+	size := int(atomic.LoadInt64(&w.queueSize))
+	if size <= 0 {
+		size = DefaultSocketQueueSize
+	}
+	return size
+}
+
+// dropRecords 丢弃队列头部的日志并计数
+func (w *SocketLogWriter) dropRecords(count int) {
+	if count > len(w.queue) {
+		count = len(w.queue)
+	}
+	if count <= 0 {
+		return
+	}
+	for i := 0; i < count; i++ {
+		w.queue[i] = nil
+	}
+	w.queue = w.queue[count:]
+	atomic.AddUint64(&w.dropped, uint64(count))
+}
+
+// send 发送一条日志
+func (w *SocketLogWriter) send(rec *Record) error {
+	var msg []byte
+	if formatter := w.formatter.Load(); formatter != nil {
+		msg = []byte(strings.TrimSuffix(formatter.Format(rec), "\n"))
+	} else {
+		// Marshall into JSON
+		js, err := json.Marshal(rec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "SocketLogWriter(%v): %v\n", w.hostport, err)
+			return nil
+		}
+		msg = js
+	}
+	w.frame = AppendFrame(w.frame[:0], w.GetFraming(), msg)
+	if max := w.datagramSize(); max > 0 && len(w.frame) > max {
+		// Truncate the message so the whole frame fits in one datagram
+		over := len(w.frame) - max
+		if over >= len(msg) {
+			over = len(msg)
+		}
+		w.frame = AppendFrame(w.frame[:0], w.GetFraming(), msg[:len(msg)-over])
+		atomic.AddUint64(&w.truncated, 1)
+	}
+	w.sock.SetWriteDeadline(time.Now().Add(w.getWriteTimeout()))
+	_, err := w.sock.Write(w.frame)
+	if err != nil && errors.Is(err, syscall.EMSGSIZE) {
+		// The system limit is lower than the configured size, the connection is still fine
+		fmt.Fprintf(os.Stderr, "SocketLogWriter(%v): %v, record dropped\n", w.hostport, err)
+		atomic.AddUint64(&w.dropped, 1)
+		return nil
+	}
+	return err
+}
+
+// datagramSize 数据报最大长度，流式连接返回0
+func (w *SocketLogWriter) datagramSize() int {
+	var size int
+	switch {
+	case strings.HasPrefix(w.proto, "udp"):
+		size = DefaultUDPDatagramSize
+	case w.proto == "unixgram" || w.proto == "unixpacket":
+		size = DefaultUnixDatagramSize
+	default:
+		return 0
+	}
+	if max := int(atomic.LoadInt64(&w.maxDatagram)); max > 0 {
+		size = max
+	}
+	return size
+}
+
 // XMLToSocketLogWriter xml创建流日志输出
 func XMLToSocketLogWriter(filename string, props []define.XMLProperty) (Writer, bool) {
+	return define.UnwrapCreater(XMLToSocketLogWriterE)(filename, props)
+}
+
+// XMLToSocketLogWriterE xml创建流日志输出，返回详细错误
+func XMLToSocketLogWriterE(filename string, props []define.XMLProperty) (Writer, error) {
+	return define.ParserCreater(XMLParseSocketLogWriter)(filename, props)
+}
+
+// XMLParseSocketLogWriter 解析流日志输出的xml配置，返回打开函数，解析时不打开连接
+func XMLParseSocketLogWriter(filename string, props []define.XMLProperty) (define.WriterOpener, error) {
 	endpoint := ""
 	protocol := "udp"
+	queuesize := 0
+	maxdatagram := 0
+	framing := FramingNone
+	tlsOpts := TLSOptions{}
+	tlsEnabled := false
+	var reconnectMin, reconnectMax, writeTimeout time.Duration
+	cerr := define.NewConfigError(filename)
 
 	// Parse properties
 	for _, prop := range props {
+		var err error
+		if parseTLSProperty(&tlsOpts, &tlsEnabled, prop.Name, strings.Trim(prop.Value, " \r\n")) {
+			continue
+		}
 		switch prop.Name {
 		case "endpoint":
 			endpoint = strings.Trim(prop.Value, " \r\n")
 		case "protocol":
 			protocol = strings.Trim(prop.Value, " \r\n")
+		case "framing":
+			framing, err = ParseFraming(strings.Trim(prop.Value, " \r\n"))
+		case "maxdatagram":
+			maxdatagram, err = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1024)
+		case "queuesize":
+			queuesize, err = strconv.Atoi(strings.Trim(prop.Value, " \r\n"))
+		case "reconnectmin":
+			reconnectMin, err = time.ParseDuration(strings.Trim(prop.Value, " \r\n"))
+		case "reconnectmax":
+			reconnectMax, err = time.ParseDuration(strings.Trim(prop.Value, " \r\n"))
+		case "writetimeout":
+			writeTimeout, err = time.ParseDuration(strings.Trim(prop.Value, " \r\n"))
 		default:
-			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for file filter in %s\n", prop.Name, filename)
+			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for socket filter in %s\n", prop.Name, filename)
+		}
+		if err != nil {
+			cerr.Addf("Invalid value %q for property \"%s\" of socket filter: %v", prop.Value, prop.Name, err)
 		}
 	}
 
 	// Check properties
 	if len(endpoint) == 0 {
-		fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Required property \"%s\" for file filter missing in %s\n", "endpoint", filename)
-		return nil, false
+		cerr.Addf("Required property \"%s\" for socket filter missing", "endpoint")
+	}
+	switch protocol {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "unix", "unixgram", "unixpacket":
+	default:
+		cerr.Addf("Unknown protocol %q for socket filter, use tcp, udp, unix or unixgram", protocol)
+	}
+	var tlsConfig *tls.Config
+	if tlsEnabled {
+		var err error
+		if strings.HasPrefix(protocol, "tcp") == false {
+			cerr.Addf("TLS of socket filter requires protocol tcp, got %q", protocol)
+		} else if tlsConfig, err = tlsOpts.Config(); err != nil {
+			cerr.Addf("Invalid TLS settings of socket filter: %v", err)
+		}
+	}
+	if err := cerr.Err(); err != nil {
+		return nil, err
 	}
 
-	return NewSocketLogWriter(protocol, endpoint), true
+	return func() (Writer, error) {
+		slw := NewTLSSocketLogWriter(protocol, endpoint, tlsConfig)
+		slw.SetFraming(framing)
+		slw.SetMaxDatagramSize(maxdatagram)
+		slw.SetQueueSize(queuesize)
+		slw.SetReconnect(reconnectMin, reconnectMax)
+		slw.SetWriteTimeout(writeTimeout)
+		return slw, nil
+	}, nil
 }
@@ -0,0 +1,160 @@
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+// freeAddr 获取一个当前无人监听的本地地址
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+// waitFor 等待条件成立，超时失败
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for cond() == false {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSocketLogWriterReconnect(t *testing.T) {
+	addr := freeAddr(t)
+	w := NewSocketLogWriter("tcp", addr)
+	if w == nil {
+		t.Fatal("writer not created while collector is down")
+	}
+	w.SetFormatter(NewRecordFormatter("%M")).SetFraming(FramingNewline).SetQueueSize(10)
+	w.SetReconnect(10*time.Millisecond, 20*time.Millisecond)
+
+	// 断开期间只保留最新的10条
+	for i := 0; i < 30; i++ {
+		w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: fmt.Sprintf("queued %d", i)})
+		time.Sleep(time.Millisecond)
+	}
+	waitFor(t, "dropped records", func() bool { return w.Dropped() == 20 })
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for i := 20; i < 30; i++ {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		line, err := ReadFrame(reader, FramingNewline)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("queued %d", i); string(line) != want {
+			t.Fatalf("got %q, want %q", line, want)
+		}
+	}
+
+	w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: "connected"})
+	w.Close()
+	line, err := ReadFrame(reader, FramingNewline)
+	if err != nil || string(line) != "connected" {
+		t.Fatalf("got %q, %v", line, err)
+	}
+	if dropped := w.Dropped(); dropped != 20 {
+		t.Fatalf("dropped = %d, want 20", dropped)
+	}
+}
+
+func TestSocketLogWriterBurst(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	const N = 10000
+	received := make(chan int, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- 0
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		count := 0
+		for ; count < N; count++ {
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			if _, err := ReadFrame(reader, FramingNewline); err != nil {
+				break
+			}
+		}
+		received <- count
+	}()
+
+	w := NewSocketLogWriter("tcp", ln.Addr().String())
+	w.SetFormatter(NewRecordFormatter("%M")).SetFraming(FramingNewline)
+	for i := 0; i < N; i++ {
+		w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: fmt.Sprintf("burst %d", i)})
+	}
+	w.Close()
+	if count := <-received; count != N {
+		t.Fatalf("collector received %d records, want %d", count, N)
+	}
+	if dropped := w.Dropped(); dropped != 0 {
+		t.Fatalf("dropped = %d, want 0", dropped)
+	}
+}
+
+func TestSocketLogWriterStalled(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	// 对端不读取，写超时后视为断开，日志进入有界队列
+	w := NewSocketLogWriter("tcp", ln.Addr().String())
+	w.SetFormatter(NewRecordFormatter("%M")).SetFraming(FramingNewline).SetQueueSize(10)
+	w.SetWriteTimeout(200*time.Millisecond).SetReconnect(50*time.Millisecond, 100*time.Millisecond)
+	conn := <-accepted
+	defer conn.Close()
+
+	big := strings.Repeat("x", 1024*1024)
+	start := time.Now()
+	for i := 0; i < 20*define.LogBufferLength; i++ {
+		w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: big})
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("LogWrite blocked for %v", elapsed)
+	}
+	if w.Dropped() == 0 {
+		t.Fatal("no records dropped while the collector is stalled")
+	}
+	w.Close()
+}
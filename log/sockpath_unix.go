@@ -0,0 +1,33 @@
+//go:build !windows
+// +build !windows
+
+package log
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+const accessWrite = 0x2 // W_OK
+
+// checkSocketPath 检查unix socket路径存在、是socket且可写，抽象地址 (@开头) 不检查
+func checkSocketPath(path string) error {
+	if len(path) <= 0 || path[0] == '@' {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("socket path %q does not exist", path)
+		}
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%q is not a socket", path)
+	}
+	if err := syscall.Access(path, accessWrite); err != nil {
+		return fmt.Errorf("socket path %q is not writable: %v", path, err)
+	}
+	return nil
+}
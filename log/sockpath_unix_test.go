@@ -0,0 +1,172 @@
+//go:build !windows
+// +build !windows
+
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+func newSocketDir(t *testing.T) string {
+	t.Helper()
+	// unix socket 路径长度有限，使用较短的临时目录
+	dir, err := ioutil.TempDir("", "l4g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestCheckSocketPath(t *testing.T) {
+	dir := newSocketDir(t)
+	defer os.RemoveAll(dir)
+
+	sock := filepath.Join(dir, "agent.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	regular := filepath.Join(dir, "regular")
+	if err := ioutil.WriteFile(regular, nil, 0660); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{sock, ""},
+		{"@abstract", ""},
+		{filepath.Join(dir, "missing.sock"), "does not exist"},
+		{regular, "is not a socket"},
+	}
+	for _, test := range tests {
+		err := checkSocketPath(test.path)
+		if (err == nil) != (len(test.want) == 0) || (err != nil && strings.Contains(err.Error(), test.want) == false) {
+			t.Errorf("checkSocketPath(%q) = %v, want %q", test.path, err, test.want)
+		}
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to any socket, skip the permission check")
+	}
+	if err := os.Chmod(sock, 0444); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSocketPath(sock); err == nil || strings.Contains(err.Error(), "is not writable") == false {
+		t.Errorf("checkSocketPath(read-only) = %v", err)
+	}
+}
+
+func TestUnixgramDatagramSize(t *testing.T) {
+	dir := newSocketDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "agent.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	read := func() string {
+		t.Helper()
+		buf := make([]byte, 1024*1024)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:n])
+	}
+
+	// 超过最大数据报长度时截断
+	w := NewSocketLogWriter("unixgram", path)
+	w.SetFormatter(NewRecordFormatter("%M")).SetMaxDatagramSize(64)
+	w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: strings.Repeat("x", 200)})
+	if got := read(); got != strings.Repeat("x", 64) {
+		t.Fatalf("got %d bytes, want 64", len(got))
+	}
+	waitFor(t, "truncated count", func() bool { return w.Truncated() == 1 })
+
+	// 超过系统限制时丢弃该条日志，连接仍可用
+	w.SetMaxDatagramSize(8 * 1024 * 1024)
+	w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: strings.Repeat("y", 4*1024*1024)})
+	w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: "after"})
+	if got := read(); got != "after" {
+		t.Fatalf("got %d bytes, want \"after\"", len(got))
+	}
+	w.Close()
+	if dropped := w.Dropped(); dropped != 1 {
+		t.Fatalf("dropped = %d, want 1", dropped)
+	}
+}
+
+func TestUnixSocketReconnect(t *testing.T) {
+	dir := newSocketDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "agent.sock")
+	listen := func() (*net.UnixListener, net.Conn) {
+		t.Helper()
+		ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ln.SetDeadline(time.Now().Add(5 * time.Second))
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		return ln, conn
+	}
+	readLine := func(reader *bufio.Reader) string {
+		t.Helper()
+		line, err := ReadFrame(reader, FramingNewline)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(line)
+	}
+
+	// 启动时sidecar未就绪，路径不存在
+	w := NewSocketLogWriter("unix", path)
+	w.SetFormatter(NewRecordFormatter("%M")).SetFraming(FramingNewline)
+	w.SetReconnect(10*time.Millisecond, 20*time.Millisecond)
+	w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: "before"})
+	ln, conn := listen()
+	if got := readLine(bufio.NewReader(conn)); got != "before" {
+		t.Fatalf("got %q, want \"before\"", got)
+	}
+
+	// sidecar重启，重新创建socket文件
+	conn.Close()
+	ln.Close()
+	for i := 0; i < 5; i++ {
+		w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: fmt.Sprintf("after %d", i)})
+	}
+	ln, conn = listen()
+	defer ln.Close()
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for i := 0; i < 5; i++ {
+		if got, want := readLine(reader), fmt.Sprintf("after %d", i); got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+	w.Close()
+	if dropped := w.Dropped(); dropped != 0 {
+		t.Fatalf("dropped = %d, want 0", dropped)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package log
+
+// checkSocketPath windows 下不检查unix socket路径
+func checkSocketPath(path string) error {
+	return nil
+}
@@ -0,0 +1,322 @@
+package log
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+// syslog 格式
+const (
+	SyslogRFC5424 = "rfc5424"
+	SyslogRFC3164 = "rfc3164"
+)
+
+// 常量定义
+const (
+	DefaultSyslogSDID = "fields@32473" // 字段的structured-data id，32473 为文档保留的企业号
+)
+
+// syslog facility
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// 本地syslog地址
+var syslogLocalPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogSeverity 日志等级对应的syslog severity
+func SyslogSeverity(lvl uint8) int {
+	switch lvl {
+	case define.REPORT:
+		return 5 // notice
+	case define.FATAL:
+		return 2 // crit
+	case define.ERROR:
+		return 3 // err
+	case define.WARNING:
+		return 4 // warning
+	case define.INFO:
+		return 6 // info
+	}
+	return 7 // debug
+}
+
+// ParseSyslogFacility 解析facility名称，如 user、daemon、local0，也可为数字
+func ParseSyslogFacility(name string) (int, error) {
+	if facility, ok := syslogFacilities[strings.ToLower(name)]; ok {
+		return facility, nil
+	}
+	facility, err := strconv.Atoi(name)
+	if err != nil || facility < 0 || facility > 23 {
+		return 0, fmt.Errorf("unknown syslog facility %q", name)
+	}
+	return facility, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////
+
+// SyslogFormatter syslog消息格式化
+// RFC 5424 时字段输出为structured-data，RFC 3164 时字段以 key=value 附加在消息后
+type SyslogFormatter struct {
+	Protocol string // SyslogRFC5424 或 SyslogRFC3164
+	Facility int
+	AppName  string
+	Hostname string
+	SDID     string // 字段的structured-data id，为空时不输出字段
+	pid      string
+}
+
+// NewSyslogFormatter 创建syslog格式化器，默认 RFC 5424，facility 为 user
+func NewSyslogFormatter() *SyslogFormatter {
+	hostname, _ := os.Hostname()
+	return &SyslogFormatter{
+		Protocol: SyslogRFC5424,
+		Facility: syslogFacilities["user"],
+		AppName:  filepath.Base(os.Args[0]),
+		Hostname: hostname,
+		SDID:     DefaultSyslogSDID,
+		pid:      strconv.Itoa(os.Getpid()),
+	}
+}
+
+// Format 格式化为一条syslog消息
+func (f *SyslogFormatter) Format(rec *Record) string {
+	if rec == nil {
+		return ""
+	}
+	pri := f.Facility*8 + SyslogSeverity(rec.Level)
+	out := bytes.NewBuffer(make([]byte, 0, 256))
+	if f.Protocol == SyslogRFC3164 {
+		// <PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG
+		fmt.Fprintf(out, "<%d>%s %s %s[%s]: %s%s",
+			pri, rec.Created.Format("Jan _2 15:04:05"),
+			syslogHeader(f.Hostname, 255), syslogHeader(f.AppName, 32), f.procID(),
+			rec.Message, formatFields(rec))
+		return out.String()
+	}
+
+	// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG
+	fmt.Fprintf(out, "<%d>1 %s %s %s %s - ",
+		pri, rec.Created.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeader(f.Hostname, 255), syslogHeader(f.AppName, 48), f.procID())
+	if len(rec.Fields) > 0 && len(f.SDID) > 0 {
+		out.WriteByte('[')
+		out.WriteString(f.SDID)
+		for _, field := range rec.Fields {
+			out.WriteByte(' ')
+			out.WriteString(syslogParamName(field.Key))
+			out.WriteString(`="`)
+			syslogEscape(out, field.ValueString())
+			out.WriteByte('"')
+		}
+		out.WriteByte(']')
+	} else {
+		out.WriteByte('-')
+	}
+	if len(rec.Message) > 0 {
+		out.WriteByte(' ')
+		out.WriteString(rec.Message)
+	}
+	return out.String()
+}
+
+func (f *SyslogFormatter) procID() string {
+	if len(f.pid) <= 0 {
+		return strconv.Itoa(os.Getpid())
+	}
+	return f.pid
+}
+
+// syslogHeader 头部字段只能为可打印ASCII且不含空格，为空时为 "-"
+func syslogHeader(value string, max int) string {
+	out := make([]byte, 0, len(value))
+	for i := 0; i < len(value) && len(out) < max; i++ {
+		if c := value[i]; c > 32 && c < 127 {
+			out = append(out, c)
+		}
+	}
+	if len(out) <= 0 {
+		return "-"
+	}
+	return string(out)
+}
+
+// syslogParamName PARAM-NAME 不能含 = ] " 及空格，最长32
+func syslogParamName(name string) string {
+	out := make([]byte, 0, len(name))
+	for i := 0; i < len(name) && len(out) < 32; i++ {
+		switch c := name[i]; {
+		case c <= 32 || c >= 127, c == '=', c == ']', c == '"':
+			out = append(out, '_')
+		default:
+			out = append(out, c)
+		}
+	}
+	if len(out) <= 0 {
+		return "_"
+	}
+	return string(out)
+}
+
+// syslogEscape PARAM-VALUE 中的 " \ ] 需转义
+func syslogEscape(out *bytes.Buffer, value string) {
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '"', '\\', ']':
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////
+
+// NewSyslogLogWriter 创建syslog输出，基于 SocketLogWriter，断开后自动重连
+// network 为 udp/tcp/unix/unixgram，为空时连接本机syslog (/dev/log 等)
+// tcp 使用 RFC 6587 octet-counting 分帧，unix 流使用换行分帧，数据报不分帧
+func NewSyslogLogWriter(network, raddr string, formatter *SyslogFormatter) *SocketLogWriter {
+	return NewTLSSyslogLogWriter(network, raddr, formatter, nil)
+}
+
+// NewTLSSyslogLogWriter 创建TLS syslog输出 (RFC 5425)，config 为nil时不加密
+// network 为空且找不到本机syslog时返回nil
+func NewTLSSyslogLogWriter(network, raddr string, formatter *SyslogFormatter, config *tls.Config) *SocketLogWriter {
+	if formatter == nil {
+		formatter = NewSyslogFormatter()
+	}
+	if len(network) <= 0 {
+		var err error
+		if network, raddr, err = localSyslog(); err != nil {
+			fmt.Fprintf(os.Stderr, "NewSyslogLogWriter: %s\n", err)
+			return nil
+		}
+	}
+	return NewTLSSocketLogWriter(network, raddr, config).SetFormatter(formatter).SetFraming(syslogFraming(network))
+}
+
+// localSyslog 查找本机syslog地址
+func localSyslog() (string, string, error) {
+	for _, path := range syslogLocalPaths {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.Dial(network, path)
+			if err == nil {
+				conn.Close()
+				return network, path, nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("no local syslog found in %s", strings.Join(syslogLocalPaths, ", "))
+}
+
+// syslogFraming 传输方式对应的默认分帧
+func syslogFraming(network string) string {
+	switch {
+	case strings.HasPrefix(network, "tcp"):
+		return FramingOctet
+	case network == "unix":
+		return FramingNewline
+	}
+	return FramingNone
+}
+
+// XMLToSyslogLogWriter xml创建syslog日志输出
+func XMLToSyslogLogWriter(filename string, props []define.XMLProperty) (Writer, bool) {
+	return define.UnwrapCreater(XMLToSyslogLogWriterE)(filename, props)
+}
+
+// XMLToSyslogLogWriterE xml创建syslog日志输出，返回详细错误
+func XMLToSyslogLogWriterE(filename string, props []define.XMLProperty) (Writer, error) {
+	return define.ParserCreater(XMLParseSyslogLogWriter)(filename, props)
+}
+
+// XMLParseSyslogLogWriter 解析syslog日志输出的xml配置，返回打开函数，解析时不打开连接
+func XMLParseSyslogLogWriter(filename string, props []define.XMLProperty) (define.WriterOpener, error) {
+	network := ""
+	address := ""
+	framing := ""
+	formatter := NewSyslogFormatter()
+	tlsOpts := TLSOptions{}
+	tlsEnabled := false
+	cerr := define.NewConfigError(filename)
+
+	// Parse properties
+	for _, prop := range props {
+		var err error
+		value := strings.Trim(prop.Value, " \r\n")
+		if parseTLSProperty(&tlsOpts, &tlsEnabled, prop.Name, value) {
+			continue
+		}
+		switch prop.Name {
+		case "network", "protocol":
+			network = value
+		case "address", "endpoint":
+			address = value
+		case "format":
+			switch strings.ToLower(value) {
+			case SyslogRFC5424, SyslogRFC3164:
+				formatter.Protocol = strings.ToLower(value)
+			default:
+				err = fmt.Errorf("use rfc5424 or rfc3164")
+			}
+		case "facility":
+			formatter.Facility, err = ParseSyslogFacility(value)
+		case "appname", "tag":
+			formatter.AppName = value
+		case "hostname":
+			formatter.Hostname = value
+		case "sdid":
+			formatter.SDID = value
+		case "framing":
+			framing, err = ParseFraming(value)
+		default:
+			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for syslog filter in %s\n", prop.Name, filename)
+		}
+		if err != nil {
+			cerr.Addf("Invalid value %q for property \"%s\" of syslog filter: %v", prop.Value, prop.Name, err)
+		}
+	}
+
+	// Check properties
+	if len(network) > 0 && len(address) <= 0 {
+		cerr.Addf("Required property \"%s\" for syslog filter missing", "address")
+	}
+	var tlsConfig *tls.Config
+	if tlsEnabled {
+		var err error
+		if strings.HasPrefix(network, "tcp") == false {
+			cerr.Addf("TLS of syslog filter requires network tcp, got %q", network)
+		} else if tlsConfig, err = tlsOpts.Config(); err != nil {
+			cerr.Addf("Invalid TLS settings of syslog filter: %v", err)
+		}
+	}
+	if len(network) <= 0 && tlsEnabled == false {
+		var err error
+		if network, address, err = localSyslog(); err != nil {
+			cerr.Addf("Could not find local syslog for syslog filter: %v", err)
+		}
+	}
+	if err := cerr.Err(); err != nil {
+		return nil, err
+	}
+
+	return func() (Writer, error) {
+		slw := NewTLSSyslogLogWriter(network, address, formatter, tlsConfig)
+		if len(framing) > 0 {
+			slw.SetFraming(framing)
+		}
+		return slw, nil
+	}, nil
+}
@@ -0,0 +1,181 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+func newTestSyslogFormatter(protocol string) *SyslogFormatter {
+	return &SyslogFormatter{
+		Protocol: protocol,
+		Facility: syslogFacilities["local0"],
+		AppName:  "payments",
+		Hostname: "host-1",
+		SDID:     DefaultSyslogSDID,
+		pid:      "42",
+	}
+}
+
+func newSyslogRecord(lvl uint8, msg string, kvs ...interface{}) *Record {
+	return &Record{
+		Level:   lvl,
+		Created: time.Date(2026, 3, 8, 9, 5, 7, 123456789, time.UTC),
+		Message: msg,
+		Fields:  define.MakeFields(kvs...),
+	}
+}
+
+func TestSyslogPriority(t *testing.T) {
+	tests := []struct {
+		facility string
+		level    uint8
+		want     string
+	}{
+		{"kern", define.FATAL, "<2>"},
+		{"user", define.ERROR, "<11>"},
+		{"user", define.WARNING, "<12>"},
+		{"daemon", define.REPORT, "<29>"},
+		{"local0", define.INFO, "<134>"},
+		{"local7", define.DEBUG, "<191>"},
+		{"local7", define.FINEST, "<191>"},
+	}
+	for _, test := range tests {
+		f := newTestSyslogFormatter(SyslogRFC5424)
+		f.Facility = syslogFacilities[test.facility]
+		if got := f.Format(newSyslogRecord(test.level, "msg")); strings.HasPrefix(got, test.want+"1 ") == false {
+			t.Errorf("%s/%d: got %q, want prefix %q", test.facility, test.level, got, test.want)
+		}
+	}
+}
+
+func TestParseSyslogFacility(t *testing.T) {
+	tests := []struct {
+		name string
+		want int
+		bad  bool
+	}{
+		{"user", 1, false},
+		{"DAEMON", 3, false},
+		{"local7", 23, false},
+		{"16", 16, false},
+		{"0", 0, false},
+		{"24", 0, true},
+		{"-1", 0, true},
+		{"local8", 0, true},
+		{"", 0, true},
+	}
+	for _, test := range tests {
+		got, err := ParseSyslogFacility(test.name)
+		if (err != nil) != test.bad || got != test.want {
+			t.Errorf("ParseSyslogFacility(%q) = %d, %v", test.name, got, err)
+		}
+	}
+}
+
+func TestSyslogFormatRFC5424(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(f *SyslogFormatter)
+		rec    *Record
+		want   string
+	}{
+		{"no fields", nil, newSyslogRecord(define.INFO, "payment accepted"),
+			"<134>1 2026-03-08T09:05:07.123456Z host-1 payments 42 - - payment accepted"},
+		{"fields", nil, newSyslogRecord(define.INFO, "payment accepted", "user", "u-1", "amount", 3),
+			`<134>1 2026-03-08T09:05:07.123456Z host-1 payments 42 - [fields@32473 user="u-1" amount="3"] payment accepted`},
+		{"escaped values", nil, newSyslogRecord(define.INFO, "msg", "q", `say "hi"`, "path", `C:\tmp`, "br", "a]b"),
+			`<134>1 2026-03-08T09:05:07.123456Z host-1 payments 42 - [fields@32473 q="say \"hi\"" path="C:\\tmp" br="a\]b"] msg`},
+		{"sanitised names", nil, newSyslogRecord(define.INFO, "msg", "a b", 1, `k="]`, 2, "", 3, strings.Repeat("n", 40), 4),
+			`<134>1 2026-03-08T09:05:07.123456Z host-1 payments 42 - [fields@32473 a_b="1" k___="2" _="3" ` + strings.Repeat("n", 32) + `="4"] msg`},
+		{"empty sdid drops fields", func(f *SyslogFormatter) { f.SDID = "" }, newSyslogRecord(define.INFO, "msg", "user", "u-1"),
+			"<134>1 2026-03-08T09:05:07.123456Z host-1 payments 42 - - msg"},
+		{"empty message", nil, newSyslogRecord(define.INFO, ""),
+			"<134>1 2026-03-08T09:05:07.123456Z host-1 payments 42 - -"},
+		{"header sanitised", func(f *SyslogFormatter) { f.Hostname = "my host"; f.AppName = "" }, newSyslogRecord(define.INFO, "msg"),
+			"<134>1 2026-03-08T09:05:07.123456Z myhost - 42 - - msg"},
+		{"long app name", func(f *SyslogFormatter) { f.AppName = strings.Repeat("a", 60) }, newSyslogRecord(define.INFO, "msg"),
+			"<134>1 2026-03-08T09:05:07.123456Z host-1 " + strings.Repeat("a", 48) + " 42 - - msg"},
+	}
+	for _, test := range tests {
+		f := newTestSyslogFormatter(SyslogRFC5424)
+		if test.modify != nil {
+			test.modify(f)
+		}
+		if got := f.Format(test.rec); got != test.want {
+			t.Errorf("%s:\n got %q\nwant %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSyslogFormatRFC3164(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(f *SyslogFormatter)
+		rec    *Record
+		want   string
+	}{
+		{"no fields", nil, newSyslogRecord(define.WARNING, "disk almost full"),
+			"<132>Mar  8 09:05:07 host-1 payments[42]: disk almost full"},
+		{"fields", nil, newSyslogRecord(define.ERROR, "payment failed", "user", "u-1"),
+			"<131>Mar  8 09:05:07 host-1 payments[42]: payment failed user=u-1"},
+		{"long tag", func(f *SyslogFormatter) { f.AppName = strings.Repeat("t", 40) }, newSyslogRecord(define.INFO, "msg"),
+			"<134>Mar  8 09:05:07 host-1 " + strings.Repeat("t", 32) + "[42]: msg"},
+		{"empty hostname", func(f *SyslogFormatter) { f.Hostname = "" }, newSyslogRecord(define.INFO, "msg"),
+			"<134>Mar  8 09:05:07 - payments[42]: msg"},
+	}
+	for _, test := range tests {
+		f := newTestSyslogFormatter(SyslogRFC3164)
+		if test.modify != nil {
+			test.modify(f)
+		}
+		if got := f.Format(test.rec); got != test.want {
+			t.Errorf("%s:\n got %q\nwant %q", test.name, got, test.want)
+		}
+	}
+	if got := newTestSyslogFormatter(SyslogRFC3164).Format(nil); got != "" {
+		t.Errorf("Format(nil) = %q", got)
+	}
+}
+
+func TestSyslogFraming(t *testing.T) {
+	tests := []struct {
+		network string
+		want    string
+	}{
+		{"tcp", FramingOctet},
+		{"tcp4", FramingOctet},
+		{"unix", FramingNewline},
+		{"udp", FramingNone},
+		{"unixgram", FramingNone},
+	}
+	for _, test := range tests {
+		if got := syslogFraming(test.network); got != test.want {
+			t.Errorf("syslogFraming(%q) = %q, want %q", test.network, got, test.want)
+		}
+		w := NewSyslogLogWriter(test.network, "127.0.0.1:1", nil)
+		if got := w.GetFraming(); got != test.want {
+			t.Errorf("NewSyslogLogWriter(%q) framing = %q, want %q", test.network, got, test.want)
+		}
+		w.Close()
+	}
+
+	open, err := XMLParseSyslogLogWriter("test", []define.XMLProperty{
+		{Name: "network", Value: "tcp"},
+		{Name: "address", Value: "127.0.0.1:1"},
+		{Name: "framing", Value: "newline"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := w.(*SocketLogWriter).GetFraming(); got != FramingNewline {
+		t.Errorf("configured framing = %q, want %q", got, FramingNewline)
+	}
+	w.Close()
+}
@@ -4,19 +4,48 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"sync/atomic"
 
 	"github.com/lerryxiao/log4go/log/define"
+	"github.com/mattn/go-isatty"
 )
 
 var (
 	stdout = os.Stdout
+	stderr = os.Stderr
 )
 
+// 颜色模式定义
+const (
+	ColorAuto   int32 = iota // 输出为终端时着色
+	ColorAlways              // 总是着色
+	ColorNever               // 不着色
+)
+
+// levelColors 各等级的ANSI颜色
+var levelColors = []string{
+	define.FINEST:  "\x1b[90m",
+	define.FINE:    "\x1b[90m",
+	define.DEBUG:   "\x1b[36m",
+	define.TRACE:   "\x1b[34m",
+	define.INFO:    "\x1b[32m",
+	define.WARNING: "\x1b[33m",
+	define.ERROR:   "\x1b[31m",
+	define.FATAL:   "\x1b[1;31m",
+	define.REPORT:  "\x1b[35m",
+}
+
+const colorReset = "\x1b[0m"
+
 // ConsoleLogWriter 控制台日志输出
 type ConsoleLogWriter struct {
-	rec    chan *Record
-	stop   chan bool
-	rptype uint8
+	rec       chan *Record
+	stop      chan bool
+	rptype    uint8
+	formatter formatterValue // 为空时使用默认格式
+	color     int32          // 颜色模式
+	errLevel  int32          // 不低于该等级的日志输出到标准错误，0 不启用
 }
 
 // NewConsoleLogWriter 创建控制台日志输出
@@ -25,13 +54,22 @@ func NewConsoleLogWriter() *ConsoleLogWriter {
 		rec:  make(chan *Record, define.LogBufferLength),
 		stop: make(chan bool),
 	}
-	go w.run(stdout)
+	go w.run(stdout, stderr)
 	return w
 }
 
-func (w ConsoleLogWriter) run(out io.Writer) {
+// isTerminal 是否为终端
+func isTerminal(out io.Writer) bool {
+	if file, ok := out.(*os.File); ok {
+		return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+	}
+	return false
+}
+
+func (w *ConsoleLogWriter) run(out, errout io.Writer) {
 	var timestr string
 	var timestrAt int64
+	outTerm, errTerm := isTerminal(out), isTerminal(errout)
 
 	defer func() {
 		w.stop <- true
@@ -51,26 +89,59 @@ func (w ConsoleLogWriter) run(out io.Writer) {
 				if rec == nil {
 					continue
 				}
-				if at := rec.Created.UnixNano() / 1e9; at != timestrAt {
-					timestr, timestrAt = rec.Created.Format("01/02/06 15:04:05"), at
+				var line string
+				if formatter := w.formatter.Load(); formatter != nil {
+					line = formatter.Format(rec)
+				} else {
+					if at := rec.Created.UnixNano() / 1e9; at != timestrAt {
+						timestr, timestrAt = rec.Created.Format("01/02/06 15:04:05"), at
+					}
+					line = fmt.Sprint("[", timestr, "] [", define.GetLevelName(rec.Level), "] ", rec.Message, formatFields(rec), "\n")
+				}
+				target, term := out, outTerm
+				if lvl := atomic.LoadInt32(&w.errLevel); lvl > 0 && int32(rec.Level) >= lvl {
+					target, term = errout, errTerm
 				}
-				fmt.Fprint(out, "[", timestr, "] [", define.LevelStrings[rec.Level], "] ", rec.Message, "\n")
+				if w.useColor(term) {
+					line = colorize(line, rec.Level)
+				}
+				io.WriteString(target, line)
 			}
 		}
 	}
 EXIT:
 }
 
+// useColor 是否着色
+func (w *ConsoleLogWriter) useColor(term bool) bool {
+	switch atomic.LoadInt32(&w.color) {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	default:
+		return term
+	}
+}
+
+// colorize 按等级着色，换行不着色
+func colorize(line string, lvl uint8) string {
+	if int(lvl) >= len(levelColors) || len(levelColors[lvl]) <= 0 {
+		return line
+	}
+	body := strings.TrimSuffix(line, "\n")
+	return levelColors[lvl] + body + colorReset + line[len(body):]
+}
+
 // LogWrite 日志输出
 func (w *ConsoleLogWriter) LogWrite(rec *Record) {
 	w.rec <- rec
 }
 
-// Close 关闭
+// Close 关闭，缓冲中的日志输出完成后返回
 func (w *ConsoleLogWriter) Close() {
-	w.stop <- true
-	<-w.stop
 	close(w.rec)
+	<-w.stop
 }
 
 // SetReportType 设置上报类型
@@ -83,7 +154,95 @@ func (w *ConsoleLogWriter) GetReportType() uint8 {
 	return w.rptype
 }
 
+// SetFormat 设置输出格式，与文件输出相同的模板，json 为json行格式，空串恢复默认格式
+func (w *ConsoleLogWriter) SetFormat(format string) *ConsoleLogWriter {
+	if len(format) <= 0 {
+		return w.SetFormatter(nil)
+	}
+	return w.SetFormatter(NewRecordFormatter(format))
+}
+
+// SetColor 设置颜色模式 ColorAuto/ColorAlways/ColorNever
+func (w *ConsoleLogWriter) SetColor(mode int32) *ConsoleLogWriter {
+	atomic.StoreInt32(&w.color, mode)
+	return w
+}
+
+// SetStderrLevel 不低于该等级的日志输出到标准错误，0 全部输出到标准输出
+func (w *ConsoleLogWriter) SetStderrLevel(lvl uint8) *ConsoleLogWriter {
+	atomic.StoreInt32(&w.errLevel, int32(lvl))
+	return w
+}
+
+// SetFormatter 设置格式化器，nil 恢复默认格式
+func (w *ConsoleLogWriter) SetFormatter(formatter RecordFormatter) *ConsoleLogWriter {
+	w.formatter.Store(formatter)
+	return w
+}
+
 // XMLToConsoleLogWriter xml创建控制台日志输出
 func XMLToConsoleLogWriter(filename string, props []define.XMLProperty) (Writer, bool) {
-	return NewConsoleLogWriter(), true
+	return define.UnwrapCreater(XMLToConsoleLogWriterE)(filename, props)
+}
+
+// XMLToConsoleLogWriterE xml创建控制台日志输出，返回详细错误
+func XMLToConsoleLogWriterE(filename string, props []define.XMLProperty) (Writer, error) {
+	return define.ParserCreater(XMLParseConsoleLogWriter)(filename, props)
+}
+
+// XMLParseConsoleLogWriter 解析控制台日志输出的xml配置，返回打开函数
+func XMLParseConsoleLogWriter(filename string, props []define.XMLProperty) (define.WriterOpener, error) {
+	format := ""
+	jsonkeys := ""
+	color := ColorAuto
+	var errLevel uint8
+	cerr := define.NewConfigError(filename)
+
+	// Parse properties
+	for _, prop := range props {
+		value := strings.Trim(prop.Value, " \r\n")
+		switch prop.Name {
+		case "format":
+			format = value
+		case "jsonkeys":
+			jsonkeys = value
+		case "color":
+			switch strings.ToLower(value) {
+			case "", "auto":
+				color = ColorAuto
+			case "true", "1", "always":
+				color = ColorAlways
+			case "false", "0", "never":
+				color = ColorNever
+			default:
+				cerr.Addf("Invalid value %q for property \"%s\" of console filter, should be auto/true/false", prop.Value, prop.Name)
+			}
+		case "stderr":
+			switch strings.ToLower(value) {
+			case "", "false", "0":
+				errLevel = 0
+			case "true", "1":
+				errLevel = define.ERROR
+			default:
+				if errLevel = define.GetLevel(strings.ToLower(value)); errLevel == 0 {
+					cerr.Addf("Invalid value %q for property \"%s\" of console filter, should be true/false or a level", prop.Value, prop.Name)
+				}
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for console filter in %s\n", prop.Name, filename)
+		}
+	}
+	if err := cerr.Err(); err != nil {
+		return nil, err
+	}
+
+	return func() (Writer, error) {
+		clw := NewConsoleLogWriter().SetColor(color).SetStderrLevel(errLevel)
+		if format == FormatJSON {
+			clw.SetFormatter(NewJSONFormatter().SetKeys(jsonkeys))
+		} else {
+			clw.SetFormat(format)
+		}
+		return clw, nil
+	}, nil
 }
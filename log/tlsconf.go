@@ -0,0 +1,100 @@
+package log
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// TLSOptions TLS配置项，CertFile/KeyFile 同时设置时启用双向认证
+type TLSOptions struct {
+	CAFile             string // 校验服务端证书的CA，为空时使用系统CA
+	CertFile           string // 客户端证书
+	KeyFile            string // 客户端私钥
+	ServerName         string // 校验的服务端名称，为空时使用连接地址中的主机名
+	MinVersion         string // 最低版本 1.0/1.1/1.2/1.3，为空时使用 1.2
+	InsecureSkipVerify bool   // 不校验服务端证书，仅用于测试
+
+	disabled bool // xml中 tls 显式为false
+}
+
+// Config 创建 tls.Config
+func (opts *TLSOptions) Config() (*tls.Config, error) {
+	version, err := parseTLSVersion(opts.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		ServerName:         opts.ServerName,
+		MinVersion:         version,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+	if len(opts.CAFile) > 0 {
+		pem, err := ioutil.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("TLS CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if pool.AppendCertsFromPEM(pem) == false {
+			return nil, fmt.Errorf("TLS CA: no certificate found in %q", opts.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if len(opts.CertFile) > 0 || len(opts.KeyFile) > 0 {
+		if len(opts.CertFile) <= 0 || len(opts.KeyFile) <= 0 {
+			return nil, fmt.Errorf("TLS client certificate requires both cert and key")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("TLS client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// parseTLSVersion 解析TLS版本
+func parseTLSVersion(str string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(str), "tls") {
+	case "", "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unknown TLS version %q", str)
+}
+
+// parseTLSProperty 解析xml中tls开头的属性，不是tls属性时返回false
+// tls 为true时启用，设置其它属性同样启用: tlsca, tlscert, tlskey, tlsservername, tlsminversion, tlsskipverify
+// tls 显式为false时不论属性顺序均不启用
+func parseTLSProperty(opts *TLSOptions, enabled *bool, name, value string) bool {
+	if name == "tls" {
+		opts.disabled = value == "false"
+		*enabled = opts.disabled == false
+		return true
+	}
+	switch name {
+	case "tlsca":
+		opts.CAFile = value
+	case "tlscert":
+		opts.CertFile = value
+	case "tlskey":
+		opts.KeyFile = value
+	case "tlsservername":
+		opts.ServerName = value
+	case "tlsminversion":
+		opts.MinVersion = value
+	case "tlsskipverify":
+		opts.InsecureSkipVerify = value != "false"
+	default:
+		return false
+	}
+	*enabled = opts.disabled == false
+	return true
+}
@@ -0,0 +1,224 @@
+package log
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lerryxiao/log4go/log/define"
+)
+
+// testPKI 测试用CA及服务端、客户端证书，PEM文件写入临时目录
+type testPKI struct {
+	dir    string
+	pool   *x509.CertPool
+	server tls.Certificate
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "log4go-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pki := &testPKI{dir: dir, pool: x509.NewCertPool()}
+
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "log4go test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+	pki.pool.AddCert(ca)
+	pki.writePEM(t, "ca.pem", "CERTIFICATE", caDER)
+
+	issue := func(serial int64, name string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{name},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der, keyDER
+	}
+
+	der, keyDER := issue(2, "localhost", x509.ExtKeyUsageServerAuth)
+	pki.server = tls.Certificate{Certificate: [][]byte{der}}
+	pki.server.PrivateKey, _ = x509.ParseECPrivateKey(keyDER)
+
+	der, keyDER = issue(3, "client", x509.ExtKeyUsageClientAuth)
+	pki.writePEM(t, "client.pem", "CERTIFICATE", der)
+	pki.writePEM(t, "client-key.pem", "EC PRIVATE KEY", keyDER)
+	return pki
+}
+
+func (pki *testPKI) writePEM(t *testing.T, name, kind string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+	if err := ioutil.WriteFile(filepath.Join(pki.dir, name), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (pki *testPKI) path(name string) string {
+	return filepath.Join(pki.dir, name)
+}
+
+// serverConfig 要求并校验客户端证书的服务端配置
+func (pki *testPKI) serverConfig() *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{pki.server},
+		ClientCAs:    pki.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+}
+
+// clientConfig 通过xml属性创建客户端配置
+func (pki *testPKI) clientConfig(t *testing.T) *tls.Config {
+	t.Helper()
+	opts := TLSOptions{}
+	enabled := false
+	for _, prop := range []define.XMLProperty{
+		{Name: "tlsca", Value: pki.path("ca.pem")},
+		{Name: "tlscert", Value: pki.path("client.pem")},
+		{Name: "tlskey", Value: pki.path("client-key.pem")},
+		{Name: "tlsservername", Value: "localhost"},
+	} {
+		parseTLSProperty(&opts, &enabled, prop.Name, prop.Value)
+	}
+	if enabled == false {
+		t.Fatal("tls properties did not enable TLS")
+	}
+	config, err := opts.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestParseTLSProperty(t *testing.T) {
+	tests := []struct {
+		props []define.XMLProperty
+		want  bool
+	}{
+		{[]define.XMLProperty{{Name: "tlsca", Value: "ca.pem"}}, true},
+		{[]define.XMLProperty{{Name: "tls", Value: "true"}}, true},
+		{[]define.XMLProperty{{Name: "tls", Value: "false"}, {Name: "tlsca", Value: "ca.pem"}}, false},
+		{[]define.XMLProperty{{Name: "tlsca", Value: "ca.pem"}, {Name: "tls", Value: "false"}}, false},
+		{[]define.XMLProperty{{Name: "tls", Value: "false"}, {Name: "tls", Value: "true"}}, true},
+		{[]define.XMLProperty{{Name: "endpoint", Value: "127.0.0.1:514"}}, false},
+	}
+	for i, test := range tests {
+		opts := TLSOptions{}
+		enabled := false
+		for _, prop := range test.props {
+			parseTLSProperty(&opts, &enabled, prop.Name, prop.Value)
+		}
+		if enabled != test.want {
+			t.Errorf("#%d %v: enabled = %v, want %v", i, test.props, enabled, test.want)
+		}
+	}
+}
+
+func TestSocketLogWriterMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	defer os.RemoveAll(pki.dir)
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", pki.serverConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	w := NewTLSSocketLogWriter("tcp", ln.Addr().String(), pki.clientConfig(t))
+	w.SetFormatter(NewRecordFormatter("%M")).SetFraming(FramingNewline)
+	w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: "over tls"})
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	line, err := ReadFrame(bufio.NewReader(conn), FramingNewline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(line) != "over tls" {
+		t.Fatalf("got %q", line)
+	}
+	state := conn.(*tls.Conn).ConnectionState()
+	if len(state.PeerCertificates) == 0 || state.PeerCertificates[0].Subject.CommonName != "client" {
+		t.Fatal("client certificate not presented")
+	}
+	w.Close()
+}
+
+func TestHTTPLogWriterMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	defer os.RemoveAll(pki.dir)
+
+	bodies := make(chan string, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies <- string(body)
+	}))
+	server.TLS = pki.serverConfig()
+	server.StartTLS()
+	defer server.Close()
+
+	w := NewHTTPLogWriter(server.URL, nil, 1).SetTLSConfig(pki.clientConfig(t))
+	transport, ok := w.getClient().Transport.(*http.Transport)
+	if ok == false || transport.TLSHandshakeTimeout <= 0 || transport.IdleConnTimeout <= 0 {
+		t.Fatal("transport does not keep the default timeouts")
+	}
+	w.LogWrite(&Record{Level: define.INFO, Created: time.Now(), Message: "over https"})
+	select {
+	case body := <-bodies:
+		if body != "over https" {
+			t.Fatalf("got %q", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request not received")
+	}
+	w.Close()
+}
@@ -1,11 +1,12 @@
 package log4go
 
 import (
-	"encoding/xml"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/lerryxiao/log4go/log"
 	"github.com/lerryxiao/log4go/log/define"
@@ -24,97 +25,237 @@ const (
 ////////////////////////////////////////////////////////////////////////////////////
 
 var (
-	createFuns = map[string]define.WriterCreater{
-		"console": log.XMLToConsoleLogWriter,
-		"file":    log.XMLToFileLogWriter,
-		"xml":     log.XMLToXMLLogWriter,
-		"socket":  log.XMLToSocketLogWriter,
-		"http":    log.XMLToHTTPLogWriter,
+	createFuns = map[string]define.WriterParser{
+		"console": log.XMLParseConsoleLogWriter,
+		"file":    log.XMLParseFileLogWriter,
+		"xml":     log.XMLParseXMLLogWriter,
+		"socket":  log.XMLParseSocketLogWriter,
+		"http":    log.XMLParseHTTPLogWriter,
+		"syslog":  log.XMLParseSyslogLogWriter,
 	}
+
+	loadMutex sync.Mutex // 串行化配置加载，保证复用的过滤器不被并发的加载关闭
 )
 
 ////////////////////////////////////////////////////////////////////////////////////
 
 // NewLogger 创建
 func NewLogger() Logger {
-	return make(Logger)
+	return define.NewLogger()
 }
 
 // RegistCreater 注册创建者
 func RegistCreater(key string, fun define.WriterCreater) {
+	RegistCreaterE(key, define.WrapCreater(fun))
+}
+
+// RegistCreaterE 注册返回详细错误的创建者，配置在打开writer时才校验
+func RegistCreaterE(key string, fun define.WriterCreaterE) {
+	RegistParser(key, define.CreaterParser(fun))
+}
+
+// RegistParser 注册解析函数，加载配置时所有过滤器校验通过后才打开writer
+func RegistParser(key string, fun define.WriterParser) {
 	if val, ok := createFuns[key]; ok == false || val == nil {
 		createFuns[key] = fun
 	}
 }
 
 // LoadConfiguration Load XML configuration; see examples/example.xml for documentation
+// YAML (.yaml/.yml) and JSON (.json) files are detected by extension
+// Any error is printed to stderr and exits the process, use LoadConfigurationE to handle it
 func LoadConfiguration(filename string, log define.Logger) {
-	log.Close()
+	if err := LoadConfigurationE(filename, log); err != nil {
+		fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: %v\n", err)
+		os.Exit(1)
+	}
+}
 
-	// Open the configuration file
+// LoadConfigurationE 加载配置文件，格式由扩展名决定，失败时返回所有错误且不修改logger
+func LoadConfigurationE(filename string, log define.Logger) error {
 	fd, err := os.Open(filename)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Could not open %q for reading: %s\n", filename, err)
-		os.Exit(1)
+		return fmt.Errorf("Could not open %q for reading: %s", filename, err)
 	}
+	defer fd.Close()
+	return loadConfiguration(filename, define.GetConfigFormat(filename), fd, log)
+}
 
-	contents, err := ioutil.ReadAll(fd)
+// LoadConfigurationFrom 从reader加载xml配置，失败时返回所有错误且不修改logger
+func LoadConfigurationFrom(reader io.Reader, log define.Logger) error {
+	return loadConfiguration("reader", define.ConfigXML, reader, log)
+}
+
+// LoadConfigurationFormat 从reader加载指定格式(xml/yaml/json)的配置，失败时返回所有错误且不修改logger
+func LoadConfigurationFormat(reader io.Reader, format string, log define.Logger) error {
+	return loadConfiguration("reader", format, reader, log)
+}
+
+func loadConfiguration(source, format string, reader io.Reader, log define.Logger) error {
+	contents, err := ioutil.ReadAll(reader)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Could not read %q: %s\n", filename, err)
-		os.Exit(1)
+		return fmt.Errorf("Could not read %q: %s", source, err)
 	}
 
-	xc := new(define.XMLLoggerConfig)
-	if err := xml.Unmarshal(contents, xc); err != nil {
-		fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Could not parse XML configuration in %q: %s\n", filename, err)
-		os.Exit(1)
+	xc, err := define.ParseConfig(format, contents)
+	if err != nil {
+		return fmt.Errorf("Could not parse %s configuration in %q: %s", strings.ToUpper(format), source, err)
 	}
 
+	loadMutex.Lock()
+	defer loadMutex.Unlock()
+	filters, err := buildFilters(source, xc, log.Filters())
+	if err != nil {
+		return err
+	}
+	log.ReplaceFilters(filters)
+	return nil
+}
+
+// buildFilters 根据配置创建过滤器，所有过滤器校验通过后才打开writer，有错误时关闭已打开的writer
+// current 中同名且writer配置不变的过滤器被复用，不重新打开输出目标
+// 复用的过滤器等级配置不变时保留运行时修改的等级，等级配置变化时以配置为准
+func buildFilters(source string, xc *define.XMLLoggerConfig, current define.Filters) (define.Filters, error) {
+	type pending struct {
+		prefix     string
+		tag        string
+		open       define.WriterOpener
+		rptType    uint8
+		lvl        uint8
+		sources    []define.SourceLevel
+		writerConf string
+		levelConf  string
+	}
 	var (
-		lvl          uint8
-		bad, enabled bool
+		lvl     uint8
+		filters = make(define.Filters)
+		opens   []pending
+		created []*define.Filter
+		updates []func()
+		cerr    = define.NewConfigError(source)
 	)
 
-	for _, xmlfilt := range xc.Filter {
-		bad, enabled = false, false
+	for index, xmlfilt := range xc.Filter {
+		prefix := fmt.Sprintf("filter #%d (%s)", index+1, xmlfilt.Tag)
+		bad := false
 		if len(xmlfilt.Enabled) == 0 {
-			fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Required attribute %s for filter missing in %s\n", "enabled", filename)
-			bad = true
-		} else if strings.ToLower(xmlfilt.Enabled) == "true" || xmlfilt.Enabled == "1" {
-			enabled = true
-		}
-		if enabled == false {
+			cerr.Addf("%s: Required attribute %s for filter missing", prefix, "enabled")
+			continue
+		} else if strings.ToLower(xmlfilt.Enabled) != "true" && xmlfilt.Enabled != "1" {
 			continue
 		}
 		if len(xmlfilt.Tag) == 0 {
-			fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Required child <%s> for filter missing in %s\n", "tag", filename)
+			cerr.Addf("%s: Required child <%s> for filter missing", prefix, "tag")
+			bad = true
+		} else if _, ok := filters[xmlfilt.Tag]; ok {
+			cerr.Addf("%s: Duplicate filter tag %q", prefix, xmlfilt.Tag)
 			bad = true
 		}
 		if len(xmlfilt.Type) == 0 {
-			fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Required child <%s> for filter missing in %s\n", "type", filename)
+			cerr.Addf("%s: Required child <%s> for filter missing", prefix, "type")
 			bad = true
 		}
 		if len(xmlfilt.Level) == 0 {
-			fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Required child <%s> for filter missing in %s\n", "level", filename)
+			cerr.Addf("%s: Required child <%s> for filter missing", prefix, "level")
 			bad = true
-		}
-		if lvl = define.GetLevel(xmlfilt.Level); lvl == 0 {
-			fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Required child <%s> for filter has unknown value in %s: %s\n", "level", filename, xmlfilt.Level)
+		} else if lvl = define.GetLevel(xmlfilt.Level); lvl == 0 {
+			cerr.Addf("%s: Required child <%s> for filter has unknown value: %s", prefix, "level", xmlfilt.Level)
 			bad = true
 		}
+		sources := make([]define.SourceLevel, 0, len(xmlfilt.Source))
+		for _, xmlsrc := range xmlfilt.Source {
+			source := strings.TrimSpace(xmlsrc.Source)
+			srclvl := define.GetLevel(strings.TrimSpace(xmlsrc.Level))
+			if len(source) == 0 {
+				cerr.Addf("%s: Required value for <%s> missing", prefix, "source")
+				bad = true
+			} else if srclvl == 0 {
+				cerr.Addf("%s: Attribute level for <source>%s</source> has unknown value: %s", prefix, source, xmlsrc.Level)
+				bad = true
+			} else {
+				sources = append(sources, define.SourceLevel{Source: source, Level: srclvl})
+			}
+		}
 		fun, ok := createFuns[xmlfilt.Type]
-		if fun == nil || ok == false {
-			fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Could not load XML configuration in %s: unknown filter type \"%s\"\n", filename, xmlfilt.Type)
+		if len(xmlfilt.Type) > 0 && (fun == nil || ok == false) {
+			cerr.Addf("%s: Unknown filter type \"%s\"", prefix, xmlfilt.Type)
 			bad = true
 		}
+		if len(xmlfilt.Tag) > 0 {
+			// Mark the tag as seen, the filter is set after opening
+			filters[xmlfilt.Tag] = nil
+		}
 		if bad {
-			os.Exit(1)
+			continue
+		}
+		writerConf, levelConf := filterConfig(&xmlfilt)
+		if old := current[xmlfilt.Tag]; old != nil {
+			if oldWriter, oldLevel := old.Config(); oldWriter == writerConf {
+				if oldLevel != levelConf {
+					lvl := lvl
+					updates = append(updates, func() {
+						old.SetLevel(lvl)
+						old.SetSourceLevels(sources)
+						old.SetConfig(writerConf, levelConf)
+					})
+				}
+				filters[xmlfilt.Tag] = old
+				continue
+			}
 		}
-		filt, good := fun(filename, xmlfilt.Property)
-		if good == false || filt == nil {
-			os.Exit(1)
+		open, err := fun(source, xmlfilt.Property)
+		if err != nil || open == nil {
+			if err == nil {
+				err = define.ErrCreateWriter
+			}
+			cerr.Merge(prefix, err)
+			continue
+		}
+		opens = append(opens, pending{
+			prefix:     prefix,
+			tag:        xmlfilt.Tag,
+			open:       open,
+			rptType:    define.GetReportType(xmlfilt.RptType),
+			lvl:        lvl,
+			sources:    sources,
+			writerConf: writerConf,
+			levelConf:  levelConf,
+		})
+	}
+	if err := cerr.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, item := range opens {
+		filt, err := item.open()
+		if err != nil || filt == nil {
+			if err == nil {
+				err = define.ErrCreateWriter
+			}
+			cerr.Merge(item.prefix, err)
+			break
 		}
-		filt.SetReportType(define.GetReportType(xmlfilt.RptType))
-		log.AddFilter(xmlfilt.Tag, filt, lvl)
+		filt.SetReportType(item.rptType)
+		filters[item.tag] = define.NewFilter(filt, item.lvl)
+		filters[item.tag].SetSourceLevels(item.sources)
+		filters[item.tag].SetConfig(item.writerConf, item.levelConf)
+		created = append(created, filters[item.tag])
 	}
+	if err := cerr.Err(); err != nil {
+		for _, filt := range created {
+			filt.Close()
+		}
+		return nil, err
+	}
+	for _, update := range updates {
+		update()
+	}
+	return filters, nil
+}
+
+// filterConfig 过滤器的writer配置与等级配置
+func filterConfig(xmlfilt *define.XMLFilter) (string, string) {
+	writer := fmt.Sprintf("%q %q %q", xmlfilt.Type, xmlfilt.RptType, xmlfilt.Property)
+	level := fmt.Sprintf("%q %q", xmlfilt.Level, xmlfilt.Source)
+	return writer, level
 }
@@ -0,0 +1,142 @@
+package log4go
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func fileConfig(dir, level, format string) string {
+	return `<logging>
+  <filter enabled="true">
+    <tag>file</tag>
+    <type>file</type>
+    <level>` + level + `</level>
+    <property name="dir">` + dir + `</property>
+    <property name="filename">app.log</property>
+    <property name="format">` + format + `</property>
+    <property name="rotate">true</property>
+  </filter>
+</logging>`
+}
+
+func loadString(t *testing.T, conf string, log Logger) error {
+	t.Helper()
+	return LoadConfigurationFrom(strings.NewReader(conf), log)
+}
+
+func TestReloadKeepsConfigOnError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log4go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	log := NewLogger()
+	if err := loadString(t, fileConfig(dir, "INFO", "%M"), log); err != nil {
+		t.Fatal(err)
+	}
+	filt := log.GetFilter("file")
+
+	bad := strings.Replace(fileConfig(dir, "INFO", "%M"), "<type>file</type>", "<type>nosuch</type>", 1)
+	if err := loadString(t, bad, log); err == nil {
+		t.Fatal("expected error for unknown filter type")
+	}
+	if err := loadString(t, fileConfig(dir, "LOUD", "%M"), log); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+	// 有效的文件过滤器变化时也不能在校验失败前打开 (rotate 会重命名正在使用的文件)
+	changed := strings.Replace(fileConfig(dir, "INFO", "%L %M"), "</logging>", `  <filter enabled="true">
+    <tag>other</tag>
+    <type>nosuch</type>
+    <level>INFO</level>
+  </filter>
+</logging>`, 1)
+	if err := loadString(t, changed, log); err == nil {
+		t.Fatal("expected error for unknown filter type")
+	}
+	if log.GetFilter("file") != filt || log.GetLevel("file") != INFO {
+		t.Fatal("running configuration changed by failed reload")
+	}
+	log.Info("still running")
+	log.Close()
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("failed reload rotated files: %d files in %s", len(files), dir)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "still running\n" {
+		t.Fatalf("unexpected content %q", data)
+	}
+}
+
+func TestReloadReusesWriters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log4go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	log := NewLogger()
+	conf := fileConfig(dir, "INFO", "%M")
+	if err := loadString(t, conf, log); err != nil {
+		t.Fatal(err)
+	}
+	log.SetLevel("file", WARNING)
+
+	const G, N = 4, 2000
+	var wg sync.WaitGroup
+	for g := 0; g < G; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < N; i++ {
+				log.Warn("line %d", i)
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		if err := loadString(t, conf, log); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	if lvl := log.GetLevel("file"); lvl != WARNING {
+		t.Fatalf("runtime level lost on reload, got %d", lvl)
+	}
+
+	// 等级配置变化时以配置为准
+	if err := loadString(t, fileConfig(dir, "ERROR", "%M"), log); err != nil {
+		t.Fatal(err)
+	}
+	if lvl := log.GetLevel("file"); lvl != ERROR {
+		t.Fatalf("configured level not applied, got %d", lvl)
+	}
+	log.Close()
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("reload rotated files: %d files in %s", len(files), dir)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("line ")); lines != G*N {
+		t.Fatalf("got %d lines, want %d", lines, G*N)
+	}
+}
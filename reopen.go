@@ -0,0 +1,58 @@
+package log4go
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// SignalReopener 收到信号时重新打开logger中的文件输出
+// 配合系统logrotate的create方式使用，在postrotate中向进程发送信号
+type SignalReopener struct {
+	log  Logger
+	sig  chan os.Signal
+	stop chan bool
+}
+
+// ReopenOnSignal 收到信号时调用 log.Reopen()，未指定信号时为SIGHUP
+// 注意 WatchConfiguration 同样监听SIGHUP，同时使用时可改用其它信号如SIGUSR1
+func ReopenOnSignal(log Logger, sigs ...os.Signal) *SignalReopener {
+	if len(sigs) <= 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	r := &SignalReopener{
+		log:  log,
+		sig:  make(chan os.Signal, 1),
+		stop: make(chan bool),
+	}
+	signal.Notify(r.sig, sigs...)
+	go r.run()
+	return r
+}
+
+// Stop 停止监听
+func (r *SignalReopener) Stop() {
+	signal.Stop(r.sig)
+	r.stop <- true
+	<-r.stop
+}
+
+func (r *SignalReopener) run() {
+	defer func() {
+		r.stop <- true
+	}()
+
+	for {
+		select {
+		case <-r.stop:
+			{
+				goto EXIT
+			}
+		case <-r.sig:
+			{
+				r.log.Reopen()
+			}
+		}
+	}
+EXIT:
+}
@@ -0,0 +1,127 @@
+package log4go
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 常量定义
+const (
+	DefaultWatchInterval = 5 * time.Second // 默认配置文件检查间隔
+)
+
+// ConfigWatcher 配置热加载，配置文件变化或收到SIGHUP时重新加载
+// 新的过滤器整体替换进logger，被移除的writer输出完缓冲后关闭
+// 配置不变的过滤器被复用，不会重新打开文件，运行时修改的等级在其等级配置变化前保留
+// 加载失败时保持当前配置不变
+type ConfigWatcher struct {
+	filename string
+	log      Logger
+	interval time.Duration
+	handler  func(error)
+
+	mutex   sync.Mutex
+	modTime time.Time
+	size    int64
+
+	sig  chan os.Signal
+	stop chan bool
+}
+
+// WatchConfiguration 监听配置文件，interval <= 0 时使用默认间隔
+// 配置文件应已通过 LoadConfiguration/LoadConfigurationE 加载过
+func WatchConfiguration(filename string, log Logger, interval time.Duration) *ConfigWatcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	w := &ConfigWatcher{
+		filename: filename,
+		log:      log,
+		interval: interval,
+		sig:      make(chan os.Signal, 1),
+		stop:     make(chan bool),
+	}
+	w.modTime, w.size = w.stat()
+	signal.Notify(w.sig, syscall.SIGHUP)
+	go w.run()
+	return w
+}
+
+// SetHandler 设置加载结果回调，成功时参数为nil
+func (w *ConfigWatcher) SetHandler(fun func(error)) *ConfigWatcher {
+	w.mutex.Lock()
+	w.handler = fun
+	w.mutex.Unlock()
+	return w
+}
+
+// Reload 立即重新加载配置
+func (w *ConfigWatcher) Reload() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.modTime, w.size = w.stat()
+	err := LoadConfigurationE(w.filename, w.log)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ConfigWatcher(%q): reload failed, keep running configuration: %v\n", w.filename, err)
+	}
+	if w.handler != nil {
+		w.handler(err)
+	}
+	return err
+}
+
+// Stop 停止监听
+func (w *ConfigWatcher) Stop() {
+	signal.Stop(w.sig)
+	w.stop <- true
+	<-w.stop
+}
+
+func (w *ConfigWatcher) run() {
+	ticker := time.NewTicker(w.interval)
+	defer func() {
+		ticker.Stop()
+		w.stop <- true
+	}()
+
+	for {
+		select {
+		case <-w.stop:
+			{
+				goto EXIT
+			}
+		case <-w.sig:
+			{
+				w.Reload()
+			}
+		case <-ticker.C:
+			{
+				if w.changed() {
+					w.Reload()
+				}
+			}
+		}
+	}
+EXIT:
+}
+
+// changed 检查配置文件是否变化
+func (w *ConfigWatcher) changed() bool {
+	modTime, size := w.stat()
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return modTime.IsZero() == false && (modTime.Equal(w.modTime) == false || size != w.size)
+}
+
+func (w *ConfigWatcher) stat() (time.Time, int64) {
+	info, err := os.Stat(w.filename)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}